@@ -4,76 +4,223 @@
 package applycmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sapcc/go-bits/errext"
 	"github.com/sapcc/go-bits/logg"
 	"github.com/sapcc/go-bits/must"
 	"github.com/spf13/cobra"
 
+	"github.com/sapcc/swift-ring-artisan/pkg/backup"
 	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/lock"
 	"github.com/sapcc/swift-ring-artisan/pkg/misc"
 	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+	"github.com/sapcc/swift-ring-artisan/pkg/swiftconf"
 )
 
 var (
-	checkChanges    bool
-	executeCommands bool
-	outputFilename  string
-	outputFormat    string
-	builderFilename string
-	ruleFilename    string
+	applyAll          bool
+	checkChanges      bool
+	executeCommands   bool
+	forceRebalance    bool
+	maxBalance        float64
+	maxDispersion     float64
+	maxMovement       float64
+	outputFilename    string
+	outputFormat      string
+	simulate          bool
+	builderDirectory  string
+	builderFilename   string
+	ruleFilename      string
+	swiftConfFilename string
+	valuesFilename    string
+	waitForLock       bool
+
+	// locks are kept referenced until the process exits which releases them
+	locks []*lock.Lock
 )
 
 // AddCommandTo adds a command to cobra.Command
 func AddCommandTo(parent *cobra.Command) {
 	cmd := &cobra.Command{
-		Use:     "apply -b <file> -r <file>",
-		Example: "  swift-ring-artisan apply -b account.builder -r swift-ring-artisan-rules.yaml",
-		Short:   "Applies rules to a swift-ring-builder file.",
+		Use: "apply -b <file> -r <file>",
+		Example: `  swift-ring-artisan apply -b account.builder -r swift-ring-artisan-rules.yaml
+  swift-ring-artisan apply --all --directory /etc/swift -r swift-ring-artisan-rules.yaml`,
+		Short: "Applies rules to a swift-ring-builder file.",
 		Long: `Generates swift-ring-builder commands based on predefined rules which get applied to the parsed output of the swift-ring-builder utility.
+		If the builder file does not exist yet, it is created with the part_power, replicas and min_part_hours from the rules.
+		Before existing builder files are changed, a snapshot of them is saved to the backups directory next to them, see the history and rollback commands.
+		The commands and the rebalance are executed on temporary copies of the builder files which replace them only if all commands succeeded
+		and the copies pass the verification: they need to match the rules and stay within the max_balance, max_dispersion and
+		max_partition_movement_percent of the rules. Otherwise the differences are printed and no builder file is changed.
+		With --simulate the copies are verified and discarded without replacing the builder files.
+		Concurrent runs on the same builder file are prevented by a lock file next to it which records who holds it.
+		A run fails if the lock is held by another run unless --wait is given.
+		With --all every builder file listed in the rule file is processed and one combined plan is generated.
+		Rule files are rendered as Go templates with the values from --values and the environment first if --values is given
+		or if their first line is "# swift-ring-artisan: template".
 		Rebalance needs to be done manually afterwards.`,
 		Run: run,
 	}
+	cmd.PersistentFlags().BoolVarP(&applyAll, "all", "a", false, "Apply the rules to all builder files listed in the rule file. Cannot be combined with --builder.")
 	cmd.PersistentFlags().BoolVarP(&checkChanges, "check", "c", false, "Wether to check if the rule file matches the ring. If it does not match the exit code is 1.")
 	cmd.PersistentFlags().BoolVarP(&executeCommands, "execute", "e", false, "Wether to execute the generated commands.")
+	cmd.PersistentFlags().BoolVar(&forceRebalance, "force", false, "Rebalance even if min_part_hours did not pass yet by running pretend_min_part_hours_passed first.")
+	cmd.PersistentFlags().Float64Var(&maxBalance, "max-balance", 0, "Refuse to replace a builder file whose balance exceeds this value after the rebalance. Overrides max_balance of the rules.")
+	cmd.PersistentFlags().Float64Var(&maxDispersion, "max-dispersion", 0, "Refuse to replace a builder file whose dispersion exceeds this value after the rebalance. Overrides max_dispersion of the rules.")
+	cmd.PersistentFlags().Float64Var(&maxMovement, "max-partition-movement-percent", 0, "Refuse to replace a builder file if the rebalance moved more partition replicas than this share. Overrides max_partition_movement_percent of the rules.")
 	cmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format. Can be either json or yaml.")
+	cmd.PersistentFlags().BoolVar(&simulate, "simulate", false, "Execute the commands and the rebalance on temporary copies of the builder files and verify the result without replacing the builder files.")
 	cmd.PersistentFlags().StringVarP(&outputFilename, "output", "o", "", "Output file to write the parsed data to.")
 	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to read and apply the changes to.")
-	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file to apply to the input data.")
+	// -d is already taken by the global --debug flag
+	cmd.PersistentFlags().StringVar(&builderDirectory, "directory", "/etc/swift", "Directory containing the builder files. Only used together with --all.")
+	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file or directory of rule files to apply to the input data.")
+	cmd.PersistentFlags().BoolVar(&waitForLock, "wait", false, "Wait until another run holding the lock of a builder file finishes instead of failing.")
+	cmd.PersistentFlags().StringVar(&valuesFilename, "values", "", "Values file for rule files which are Go templates. The values are available as .Values. If it is given, all rule files are rendered as templates.")
+	cmd.PersistentFlags().StringVarP(&swiftConfFilename, "swift-conf", "s", "", "swift.conf file to read the storage policies from. Required for rules which refer to a policy like \"policy:gold\". Object rings are validated against their policy.")
 	parent.AddCommand(cmd)
 }
 
+// ringPlan contains the changes that need to be applied to a single builder file
+type ringPlan struct {
+	builderFilename   string
+	ringRules         rules.RingRules
+	ring              builderfile.RingInfo
+	commandQueue      []string
+	confirmations     []string
+	rebalanceRequired bool
+}
+
+// requiresRebalance returns false for commands which only change meta data that is not relevant for the partition placement
+// and for the partition power increase commands which must not be followed by a rebalance
+func requiresRebalance(command string) bool {
+	return !strings.Contains(command, "set_info") && !strings.Contains(command, "set_min_part_hours") &&
+		!strings.Contains(command, "increase_partition_power")
+}
+
+func calculatePlan(builderFilename string, ringRules rules.RingRules, policies []swiftconf.StoragePolicy) ringPlan {
+	if _, err := os.Stat(builderFilename); errors.Is(err, os.ErrNotExist) {
+		logg.Info("%s does not exist yet and will be created", builderFilename)
+		commandQueue, confirmations, err := ringRules.CalculateCreation(builderFilename)
+		if err != nil {
+			logg.Fatal("%s: %s", builderFilename, err.Error())
+		}
+		return ringPlan{
+			builderFilename:   builderFilename,
+			ringRules:         ringRules,
+			commandQueue:      commandQueue,
+			confirmations:     confirmations,
+			rebalanceRequired: true,
+		}
+	}
+
+	ring := builderfile.File(builderFilename)
+
+	if policy, ok := swiftconf.PolicyForBuilder(policies, builderFilename); ok {
+		if err := policy.CheckReplicas(ring); err != nil {
+			logg.Fatal(err.Error())
+		}
+	}
+
+	commandQueue, confirmations, err := ringRules.CalculateChanges(ring, builderFilename)
+	if err != nil {
+		logg.Fatal("%s: %s", builderFilename, err.Error())
+	}
+
+	rebalanceRequired := false
+	for _, command := range commandQueue {
+		rebalanceRequired = rebalanceRequired || requiresRebalance(command)
+	}
+
+	return ringPlan{
+		builderFilename:   builderFilename,
+		ringRules:         ringRules,
+		ring:              ring,
+		commandQueue:      commandQueue,
+		confirmations:     confirmations,
+		rebalanceRequired: rebalanceRequired,
+	}
+}
+
+// acquireLock locks a builder file before its plan is calculated, so that it cannot change until the run finishes.
+// --check and --simulate do not change builder files and therefore do not lock them.
+func acquireLock(builderFilename string) {
+	if checkChanges || simulate {
+		return
+	}
+	l, err := lock.Acquire(builderFilename, waitForLock)
+	if errext.IsOfType[lock.ErrLocked](err) {
+		logg.Fatal("%s, use --wait to wait until it is released", err.Error())
+	} else if err != nil {
+		logg.Fatal(err.Error())
+	}
+	locks = append(locks, l)
+}
+
 func run(cmd *cobra.Command, args []string) {
 	_, _ = cmd, args
 
 	if outputFormat != "" && outputFormat != "json" && outputFormat != "yaml" {
 		logg.Fatal("format needs to be set to json OR yaml.")
 	}
-	if builderFilename == "" {
-		logg.Fatal("--builder needs to be set")
+	if applyAll && builderFilename != "" {
+		logg.Fatal("--all and --builder cannot be used together")
+	}
+	if !applyAll && builderFilename == "" {
+		logg.Fatal("--builder or --all needs to be set")
 	}
-	ring := builderfile.File(builderFilename)
 
 	if ruleFilename == "" {
 		logg.Fatal("--rule needs to be supplied and cannot be empty")
 	}
-	var file map[string]rules.RingRules
-	misc.ReadYAML(ruleFilename, &file)
+	file := rules.Load(ruleFilename, rules.ReadValues(valuesFilename))
 
-	builderBaseFilename := filepath.Base(builderFilename)
-	ringRules, ok := file[builderBaseFilename]
-	if !ok {
-		logg.Fatal("%s is missing key for %s", ruleFilename, builderBaseFilename)
+	var policies []swiftconf.StoragePolicy
+	if swiftConfFilename != "" {
+		policies = swiftconf.File(swiftConfFilename)
 	}
-
-	commandQueue, confirmations, err := ringRules.CalculateChanges(ring, builderFilename)
+	file, err := swiftconf.ResolvePolicyRules(file, policies)
 	if err != nil {
-		logg.Fatal(err.Error())
+		logg.Fatal("%s: %s", ruleFilename, err.Error())
+	}
+
+	var plans []ringPlan
+	if applyAll {
+		errs := rules.CheckSharedNodes(file)
+		for _, err := range errs {
+			logg.Error(err.Error())
+		}
+		if len(errs) > 0 {
+			logg.Fatal("%s contains nodes which are not consistent across rings", ruleFilename)
+		}
+
+		// the rings are always locked in the same order to not deadlock with other runs using --wait
+		for _, ringName := range rules.GetRingNames(file) {
+			acquireLock(filepath.Join(builderDirectory, ringName))
+			plans = append(plans, calculatePlan(filepath.Join(builderDirectory, ringName), file[ringName], policies))
+		}
+	} else {
+		builderBaseFilename := filepath.Base(builderFilename)
+		ringRules, ok := file[builderBaseFilename]
+		if !ok {
+			logg.Fatal("%s is missing key for %s", ruleFilename, builderBaseFilename)
+		}
+		acquireLock(builderFilename)
+		plans = append(plans, calculatePlan(builderFilename, ringRules, policies))
+	}
+
+	var commandQueue, confirmations []string
+	for _, plan := range plans {
+		commandQueue = append(commandQueue, plan.commandQueue...)
+		confirmations = append(confirmations, plan.confirmations...)
 	}
 	if len(commandQueue) == 0 {
 		os.Exit(0)
@@ -82,13 +229,16 @@ func run(cmd *cobra.Command, args []string) {
 	if executeCommands && checkChanges {
 		logg.Fatal("Cannot execute commands and check if builder and ring file matches.")
 	}
+	if simulate && (executeCommands || checkChanges) {
+		logg.Fatal("--simulate cannot be combined with --execute or --check")
+	}
 
 	if len(confirmations) > 0 {
 		for _, confirmation := range confirmations {
 			logg.Info(confirmation)
 		}
 
-		if !misc.Prompt("Please type upper-case YES to continue.", []string{"YES"}) {
+		if !simulate && !misc.Prompt("Please type upper-case YES to continue.", []string{"YES"}) {
 			logg.Fatal("Aborting")
 		}
 	}
@@ -112,53 +262,155 @@ func run(cmd *cobra.Command, args []string) {
 
 	// evaluates to true if program is run in an interactive shell and not piped
 	isInteractive := (fileInfo.Mode() & os.ModeCharDevice) != 0
-	if !executeCommands && isInteractive {
+	if !executeCommands && !simulate && isInteractive {
 		promptAnswer = misc.AskConfirmation("Do you want to apply the changes by executing the above commands?")
 	}
 
-	rebalanceRequired := false
-	if executeCommands || promptAnswer {
-		for _, command := range commandQueue {
-			// rebalance not required, if commandQueue only contains 'set_info' commands
-			rebalanceRequired = rebalanceRequired || !strings.Contains(command, "set_info")
-			args := strings.Split(command, " ")
-			cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // input is user supplied and self executed
-			stdout, err := cmd.Output()
+	if !executeCommands && !simulate && !promptAnswer {
+		os.Exit(1)
+	}
+
+	// the rebalance would fail, so refuse before executing commands whose result could not be committed anyway.
+	// A simulation refuses as well since it would pass although the real apply refuses.
+	if !forceRebalance {
+		for _, plan := range plans {
+			if secondsLeft := plan.ring.MinPartSecondsLeft(); plan.rebalanceRequired && secondsLeft > 0 {
+				logg.Fatal("%s cannot be rebalanced because min_part_hours did not pass yet (%s remaining). Use --force to rebalance anyway.", plan.builderFilename, secondsLeft)
+			}
+		}
+	}
+
+	// take a snapshot of every builder file before changing it to allow rolling back with the rollback command
+	now := time.Now()
+	for _, plan := range plans {
+		if len(plan.commandQueue) == 0 || simulate {
+			continue
+		}
+		if _, err := os.Stat(plan.builderFilename); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		snapshotFilename, err := backup.Create(plan.builderFilename, now)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+		logg.Info("Saved snapshot of %s to %s", plan.builderFilename, snapshotFilename)
+	}
+
+	// all commands are executed on copies of the builder files which only replace them once all rings succeeded
+	workspaces := make([]workspace, len(plans))
+	abort := func(format string, args ...any) {
+		for _, ws := range workspaces {
+			if ws.dir != "" {
+				ws.discard()
+			}
+		}
+		logg.Fatal("%s\nNo builder file was changed.", fmt.Sprintf(format, args...))
+	}
+
+	for i, plan := range plans {
+		if len(plan.commandQueue) == 0 {
+			continue
+		}
+		ws, err := newWorkspace(plan.builderFilename)
+		if err != nil {
+			abort("Preparing %s failed: %s", plan.builderFilename, err.Error())
+		}
+		workspaces[i] = ws
+
+		for _, command := range plan.commandQueue {
+			stdout, err := ws.run(command)
 			logg.Info(string(stdout))
 			if err != nil {
-				logg.Fatal("Command %q failed: %v", command, err.Error())
+				abort("Command %q failed: %v", command, err.Error())
 			}
 		}
-	} else {
-		os.Exit(1)
 	}
 
-	promptAnswer = false
-	action := "write_ring"
-	if rebalanceRequired {
-		action = "rebalance"
-	}
-	if !executeCommands && isInteractive {
-		promptAnswer = misc.AskConfirmation(fmt.Sprintf("Do you want to %s now?", action))
+	exitCode := 0
+	for i, plan := range plans {
+		if len(plan.commandQueue) == 0 {
+			continue
+		}
+		ws := workspaces[i]
+
+		promptAnswer = false
+		action := "write_ring"
+		if plan.rebalanceRequired {
+			action = "rebalance"
+		}
+		if !executeCommands && !simulate && isInteractive {
+			promptAnswer = misc.AskConfirmation(fmt.Sprintf("Do you want to %s %s now?", action, plan.builderFilename))
+		}
+
+		rebalanced := false
+		if executeCommands || simulate || promptAnswer {
+			rebalanced = plan.rebalanceRequired
+			if plan.rebalanceRequired && forceRebalance && plan.ring.MinPartSecondsLeft() > 0 {
+				command := plan.ring.CommandPretendMinPartHoursPassed(plan.builderFilename)
+				logg.Info(command)
+				_, err := ws.run(command)
+				if err != nil {
+					abort("Command %q failed: %v", command, err.Error())
+				}
+			}
+
+			command := fmt.Sprintf("swift-ring-builder %s %s", plan.builderFilename, action)
+			logg.Info(fmt.Sprintf("%s %s", plan.builderFilename, action))
+			stdout, err := ws.run(command)
+			// For better readablitity, split multiline outputs to separate loglines
+			for line := range strings.SplitSeq(string(stdout), "\n") {
+				if line != "" {
+					logg.Info(line)
+				}
+			}
+
+			// swift-ring-builder exits with 1 on warnings like an imperfect balance, continue with the remaining rings
+			// and report the highest exit code at the end
+			if exitError, ok := errext.As[*exec.ExitError](err); ok && exitError.ExitCode() == 1 {
+				exitCode = max(exitCode, exitError.ExitCode())
+			} else if err != nil {
+				abort("Command %q failed: %v", command, err.Error())
+			}
+		}
+
+		thresholds := rules.VerifyThresholds{MaxBalance: maxBalance, MaxDispersion: maxDispersion, MaxPartitionMovementPercent: maxMovement}
+		if err := ws.verify(plan, thresholds, rebalanced); err != nil {
+			abort("%s", err.Error())
+		}
 	}
 
-	if executeCommands || promptAnswer {
-		cmd := exec.Command("swift-ring-builder", builderFilename, action)
-		logg.Info(fmt.Sprintf("%s %s", builderFilename, action))
-		stdout, err := cmd.Output()
-		// For better readablitity, split multiline outputs to separate loglines
-		for line := range strings.SplitSeq(string(stdout), "\n") {
-			if line != "" {
-				logg.Info(line)
+	if simulate {
+		for i, plan := range plans {
+			if len(plan.commandQueue) == 0 {
+				continue
 			}
+			logg.Info("Simulation of %s passed the verification", plan.builderFilename)
+			workspaces[i].discard()
 		}
+		os.Exit(exitCode)
+	}
 
-		if exitError, ok := errext.As[*exec.ExitError](err); ok {
-			os.Exit(exitError.ExitCode())
-		} else if err != nil {
-			logg.Fatal("Command %q failed: %v", strings.Join(cmd.Args, " "), err.Error())
+	var replaced []string
+	for i, plan := range plans {
+		if len(plan.commandQueue) == 0 {
+			continue
+		}
+		if err := workspaces[i].commit(plan.builderFilename); err != nil {
+			// the copies of the remaining rings are not needed anymore
+			for _, ws := range workspaces[i:] {
+				if ws.dir != "" {
+					ws.discard()
+				}
+			}
+			result := "No other builder file was changed."
+			if len(replaced) > 0 {
+				result = "These builder files were already replaced: " + strings.Join(replaced, ", ")
+			}
+			logg.Fatal("Replacing %s failed: %s\n%s", plan.builderFilename, err.Error(), result)
 		}
+		replaced = append(replaced, plan.builderFilename)
+		logg.Info("Replaced %s", plan.builderFilename)
 	}
 
-	os.Exit(0)
+	os.Exit(exitCode)
 }
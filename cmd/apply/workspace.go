@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package applycmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sapcc/go-bits/logg"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/backup"
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// workspace is a temporary copy of a builder file on which all commands and the rebalance are executed.
+// It is located in a hidden directory next to the builder file, so that it can be renamed into place atomically.
+type workspace struct {
+	dir             string
+	builderFilename string
+}
+
+// ringFilename returns the ring file that swift-ring-builder writes for a builder file
+func ringFilename(builderFilename string) string {
+	return strings.TrimSuffix(builderFilename, ".builder") + ".ring.gz"
+}
+
+func newWorkspace(builderFilename string) (ws workspace, err error) {
+	dir, err := os.MkdirTemp(filepath.Dir(builderFilename), ".artisan-")
+	if err != nil {
+		return workspace{}, err
+	}
+	ws = workspace{dir: dir, builderFilename: filepath.Join(dir, filepath.Base(builderFilename))}
+
+	// builder files which are created by the plan do not exist yet
+	in, err := os.Open(builderFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		return ws, nil
+	} else if err != nil {
+		ws.discard()
+		return workspace{}, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(ws.builderFilename)
+	if err == nil {
+		_, err = io.Copy(out, in)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		ws.discard()
+		return workspace{}, fmt.Errorf("copying %s failed: %w", builderFilename, err)
+	}
+	return ws, nil
+}
+
+// run executes a swift-ring-builder command against the copy of the builder file.
+// The builder file is always the first argument of the generated commands.
+func (ws workspace) run(command string) ([]byte, error) {
+	args := strings.Split(command, " ")
+	args[1] = ws.builderFilename
+	return exec.Command(args[0], args[1:]...).Output() //nolint:gosec // input is user supplied and self executed
+}
+
+// commit replaces the builder file and its ring file with the copies and moves the backups swift-ring-builder wrote
+func (ws workspace) commit(builderFilename string) error {
+	if err := os.Rename(ws.builderFilename, builderFilename); err != nil {
+		return err
+	}
+	if _, err := os.Stat(ringFilename(ws.builderFilename)); err == nil {
+		if err := os.Rename(ringFilename(ws.builderFilename), ringFilename(builderFilename)); err != nil {
+			return fmt.Errorf("%s was replaced but replacing %s failed, run swift-ring-builder %s write_ring: %w",
+				builderFilename, ringFilename(builderFilename), builderFilename, err)
+		}
+	}
+
+	entries, err := os.ReadDir(backup.Dir(ws.builderFilename))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		if err := os.MkdirAll(backup.Dir(builderFilename), 0755); err != nil {
+			return err
+		}
+	}
+	for _, entry := range entries {
+		// a link fails instead of replacing an existing backup like the snapshot that apply saved before
+		src, dst := filepath.Join(backup.Dir(ws.builderFilename), entry.Name()), filepath.Join(backup.Dir(builderFilename), entry.Name())
+		err := os.Link(src, dst)
+		if errors.Is(err, os.ErrExist) {
+			logg.Error("Not keeping the backup %s of swift-ring-builder because %s already exists", entry.Name(), dst)
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	ws.discard()
+	return nil
+}
+
+// discard removes the copies
+func (ws workspace) discard() {
+	if err := os.RemoveAll(ws.dir); err != nil {
+		logg.Error("Removing %s failed: %s", ws.dir, err.Error())
+	}
+}
+
+// verify re-reads the copy of the builder file after all commands were executed and checks it against the rules
+func (ws workspace) verify(plan ringPlan, thresholds rules.VerifyThresholds, rebalanced bool) error {
+	ring, err := builderfile.Read(ws.builderFilename)
+	if err != nil {
+		return err
+	}
+	return plan.ringRules.VerifyApplied(plan.ring, ring, plan.builderFilename, thresholds, rebalanced)
+}
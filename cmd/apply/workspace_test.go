@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package applycmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/backup"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+func TestWorkspaceCommit(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 1"), 0644))
+	must.SucceedT(t, os.WriteFile(ringFilename(builderFilename), []byte("ring 1"), 0644))
+
+	ws, err := newWorkspace(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, filepath.Dir(ws.dir), dir)
+	assert.Equal(t, ws.builderFilename, filepath.Join(ws.dir, "object.builder"))
+	assert.DeepEqual(t, "copy", must.ReturnT(os.ReadFile(ws.builderFilename))(t), []byte("version 1"))
+
+	// the commands are executed against the copy
+	stdout, err := ws.run("echo " + builderFilename + " rebalance")
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, string(stdout), ws.builderFilename+" rebalance\n")
+
+	// simulate what swift-ring-builder writes during a rebalance
+	must.SucceedT(t, os.WriteFile(ws.builderFilename, []byte("version 2"), 0644))
+	must.SucceedT(t, os.WriteFile(ringFilename(ws.builderFilename), []byte("ring 2"), 0644))
+	must.SucceedT(t, os.MkdirAll(backup.Dir(ws.builderFilename), 0755))
+	must.SucceedT(t, os.WriteFile(filepath.Join(backup.Dir(ws.builderFilename), "1700000000.object.builder"), []byte("version 1"), 0644))
+
+	// the original stays untouched until the commit
+	assert.DeepEqual(t, "original", must.ReturnT(os.ReadFile(builderFilename))(t), []byte("version 1"))
+
+	must.SucceedT(t, ws.commit(builderFilename))
+	assert.DeepEqual(t, "builder", must.ReturnT(os.ReadFile(builderFilename))(t), []byte("version 2"))
+	assert.DeepEqual(t, "ring", must.ReturnT(os.ReadFile(ringFilename(builderFilename)))(t), []byte("ring 2"))
+	assert.DeepEqual(t, "backup", must.ReturnT(os.ReadFile(filepath.Join(backup.Dir(builderFilename), "1700000000.object.builder")))(t), []byte("version 1"))
+
+	_, err = os.Stat(ws.dir)
+	assert.Equal(t, errors.Is(err, os.ErrNotExist), true)
+}
+
+func TestWorkspaceNewBuilder(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+
+	// builder files which are created by the plan do not exist yet
+	ws, err := newWorkspace(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	_, err = os.Stat(ws.builderFilename)
+	assert.Equal(t, errors.Is(err, os.ErrNotExist), true)
+
+	must.SucceedT(t, os.WriteFile(ws.builderFilename, []byte("version 1"), 0644))
+	must.SucceedT(t, ws.commit(builderFilename))
+	assert.DeepEqual(t, "builder", must.ReturnT(os.ReadFile(builderFilename))(t), []byte("version 1"))
+
+	// without a ring file and backups, only the builder file is left
+	entries := must.ReturnT(os.ReadDir(dir))(t)
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestWorkspaceDiscard(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 1"), 0644))
+
+	ws, err := newWorkspace(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	must.SucceedT(t, os.WriteFile(ws.builderFilename, []byte("version 2"), 0644))
+	ws.discard()
+
+	assert.DeepEqual(t, "builder", must.ReturnT(os.ReadFile(builderFilename))(t), []byte("version 1"))
+	_, err = os.Stat(ws.dir)
+	assert.Equal(t, errors.Is(err, os.ErrNotExist), true)
+}
+
+func TestWorkspaceCommitKeepsBackups(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 1"), 0644))
+	must.SucceedT(t, os.MkdirAll(backup.Dir(builderFilename), 0755))
+	existing := filepath.Join(backup.Dir(builderFilename), "1700000000.object.builder")
+	must.SucceedT(t, os.WriteFile(existing, []byte("version 0"), 0644))
+
+	ws, err := newWorkspace(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	must.SucceedT(t, os.WriteFile(ws.builderFilename, []byte("version 2"), 0644))
+	must.SucceedT(t, os.MkdirAll(backup.Dir(ws.builderFilename), 0755))
+	must.SucceedT(t, os.WriteFile(filepath.Join(backup.Dir(ws.builderFilename), "1700000000.object.builder"), []byte("version 2"), 0644))
+
+	// an existing backup is never replaced
+	must.SucceedT(t, ws.commit(builderFilename))
+	assert.DeepEqual(t, "builder", must.ReturnT(os.ReadFile(builderFilename))(t), []byte("version 2"))
+	assert.DeepEqual(t, "backup", must.ReturnT(os.ReadFile(existing))(t), []byte("version 0"))
+}
+
+func TestWorkspaceVerifyUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 1"), 0644))
+
+	// a builder file which cannot be read fails the verification instead of stopping the process, so that apply can discard all workspaces
+	ws, err := newWorkspace(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	defer ws.discard()
+	err = ws.verify(ringPlan{builderFilename: builderFilename}, rules.VerifyThresholds{}, false)
+	assert.ErrEqual(t, err, regexp.MustCompile(`^unpickling .*/object.builder failed: unknown opcode`))
+}
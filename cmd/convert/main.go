@@ -9,7 +9,6 @@ import (
 	"github.com/sapcc/go-bits/logg"
 	"github.com/sapcc/go-bits/must"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
 	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
 	"github.com/sapcc/swift-ring-artisan/pkg/convert"
@@ -18,9 +17,10 @@ import (
 )
 
 var (
-	baseSize        float64
+	baseSize        = 6 * misc.Terabyte
 	builderFilename string
 	outputFilename  string
+	verify          bool
 )
 
 // AddCommandTo adds a command to cobra.Command
@@ -30,13 +30,16 @@ func AddCommandTo(parent *cobra.Command) {
 		Example: "  swift-ring-artisan convert --input <file> --output <file>",
 		Short:   "Does an initial conversion from a parsed swift-ring-builder file to a rule file.",
 		Long: `Does an initial conversion from a parsed swift-ring-builder file to a rule file.
-This file should be edited and anchors & aliases should be added.
-Currently this is not possible from within go due to a bug in the yaml library that support anchors & aliases.`,
+Disk sizes are inferred from the weights relative to the base size, gaps in the disk numbering become broken_disks
+and ports and meta data of the nodes are kept. Weights which cannot be expressed as a disk size are kept as they are.
+Identical nodes are grouped with anchors & aliases.
+With --verify the rules are applied to the builder file in memory and the conversion fails if apply would change anything.`,
 		Run: run,
 	}
-	cmd.PersistentFlags().Float64VarP(&baseSize, "size", "s", 6, "Base size to use for the size of the disks. Defaults to 6TB. Should have a suffix like GB or TB.")
+	cmd.PersistentFlags().VarP(&baseSize, "size", "s", "Base size of the disks which get a weight of 100, like 6TB or 14TiB. Bare numbers are TB.")
 	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to read and apply the changes to.")
 	cmd.PersistentFlags().StringVarP(&outputFilename, "output", "o", "", "Output file to write the rules to.")
+	cmd.PersistentFlags().BoolVar(&verify, "verify", false, "Verify that applying the generated rules to the builder file results in no changes.")
 	parent.AddCommand(cmd)
 }
 
@@ -48,10 +51,15 @@ func run(cmd *cobra.Command, args []string) {
 	ring := builderfile.File(builderFilename)
 
 	diskRules := convert.Convert(ring, baseSize)
+	if verify {
+		if err := convert.Verify(ring, diskRules, builderFilename); err != nil {
+			logg.Fatal("Verifying the conversion of %s failed: %s", builderFilename, err.Error())
+		}
+	}
 
 	filename := filepath.Base(builderFilename)
 	file := map[string]rules.RingRules{filename: diskRules}
 
-	dataYAML := must.Return(yaml.Marshal(file))
+	dataYAML := must.Return(convert.MarshalYAML(file))
 	misc.WriteToStdoutOrFile(dataYAML, outputFilename)
 }
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package diffcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+	"github.com/sapcc/swift-ring-artisan/pkg/swiftconf"
+)
+
+// driftExitCode is used when the rings differ from the rules. Errors exit with 1 like in all other commands.
+const driftExitCode = 2
+
+var (
+	diffAll           bool
+	builderDirectory  string
+	builderFilename   string
+	ruleFilename      string
+	swiftConfFilename string
+	valuesFilename    string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use: "diff -b <file> -r <file>",
+		Example: `  swift-ring-artisan diff -b account.builder -r swift-ring-artisan-rules.yaml
+  swift-ring-artisan diff --all --directory /etc/swift -r swift-ring-artisan-rules.yaml`,
+		Short: "Reports how builder files differ from the rules.",
+		Long: `Compares builder files with the rules and prints a report of the differences grouped by category followed by a summary table.
+The categories are missing and unexpected devices, zone and port mismatches, weight drift, meta drift, overload drift and ring settings like replicas.
+The exit code is 0 if the builder files match the rules, 2 if they differ and 1 on errors.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().BoolVarP(&diffAll, "all", "a", false, "Compare all builder files listed in the rule file. Cannot be combined with --builder.")
+	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to compare with the rules.")
+	// -d is already taken by the global --debug flag
+	cmd.PersistentFlags().StringVar(&builderDirectory, "directory", "/etc/swift", "Directory containing the builder files. Only used together with --all.")
+	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file or directory of rule files to compare the builder files with.")
+	cmd.PersistentFlags().StringVar(&valuesFilename, "values", "", "Values file for rule files which are Go templates. The values are available as .Values. If it is given, all rule files are rendered as templates.")
+	cmd.PersistentFlags().StringVarP(&swiftConfFilename, "swift-conf", "s", "", "swift.conf file to read the storage policies from. Required for rules which refer to a policy like \"policy:gold\".")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if diffAll && builderFilename != "" {
+		logg.Fatal("--all and --builder cannot be used together")
+	}
+	if !diffAll && builderFilename == "" {
+		logg.Fatal("--builder or --all needs to be set")
+	}
+	if ruleFilename == "" {
+		logg.Fatal("--rule needs to be supplied and cannot be empty")
+	}
+	file := rules.Load(ruleFilename, rules.ReadValues(valuesFilename))
+
+	var policies []swiftconf.StoragePolicy
+	if swiftConfFilename != "" {
+		policies = swiftconf.File(swiftConfFilename)
+	}
+	file, err := swiftconf.ResolvePolicyRules(file, policies)
+	if err != nil {
+		logg.Fatal("%s: %s", ruleFilename, err.Error())
+	}
+
+	builderFilenames := make(map[string]string)
+	if diffAll {
+		for _, ringName := range rules.GetRingNames(file) {
+			builderFilenames[ringName] = filepath.Join(builderDirectory, ringName)
+		}
+	} else {
+		ringName := filepath.Base(builderFilename)
+		if _, ok := file[ringName]; !ok {
+			logg.Fatal("%s is missing key for %s", ruleFilename, ringName)
+		}
+		builderFilenames[ringName] = builderFilename
+	}
+
+	counts := make(map[string]map[rules.DriftCategory]int)
+	hasDrift := false
+	for _, ringName := range rules.GetRingNames(file) {
+		filename, ok := builderFilenames[ringName]
+		if !ok {
+			continue
+		}
+
+		// a builder file which does not exist yet is reported with all its devices missing
+		var ring builderfile.RingInfo
+		if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+			logg.Info("%s does not exist yet", filename)
+		} else {
+			ring = builderfile.File(filename)
+		}
+
+		drift, err := file[ringName].Drift(ring)
+		if err != nil {
+			logg.Fatal("%s: %s", filename, err.Error())
+		}
+		counts[ringName] = rules.CountDrift(drift)
+		hasDrift = hasDrift || len(drift) > 0
+
+		for _, category := range rules.DriftCategories {
+			if counts[ringName][category] == 0 {
+				continue
+			}
+			fmt.Printf("%s: %s\n", ringName, category)
+			for _, d := range drift {
+				if d.Category != category {
+					continue
+				}
+				fmt.Printf("  %s\n", d)
+			}
+			fmt.Println()
+		}
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "RING\tCATEGORY\tCOUNT")
+	for _, ringName := range rules.GetRingNames(file) {
+		if _, ok := counts[ringName]; !ok {
+			continue
+		}
+		for _, category := range rules.DriftCategories {
+			fmt.Fprintf(writer, "%s\t%s\t%d\n", ringName, category, counts[ringName][category])
+		}
+	}
+	must.Succeed(writer.Flush())
+
+	if hasDrift {
+		os.Exit(driftExitCode)
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package diffbuilderscmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "diff-builders <file> <file>",
+		Example: "  swift-ring-artisan diff-builders backups/1700000000.object.builder object.builder",
+		Short:   "Compares two versions of a builder file.",
+		Long: `Compares two versions of a builder file, e.g. before and after a rebalance or a backup with the current builder file.
+Shows changes of the version, overload, balance and dispersion of the ring as well as added and removed devices
+and changes of the weight, meta and partition count of the devices. Devices are matched by their IP and name.`,
+		Args: cobra.ExactArgs(2),
+		Run:  run,
+	}
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_ = cmd
+
+	diff := builderfile.Diff(builderfile.File(args[0]), builderfile.File(args[1]))
+	if diff.IsEmpty() {
+		fmt.Println("The builder files do not differ.")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(diff.Changes) > 0 {
+		fmt.Fprintln(writer, "RING\tBEFORE\tAFTER\tDELTA")
+		for _, change := range diff.Changes {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", change.Field, change.Before, change.After, change.Delta)
+		}
+		fmt.Fprintln(writer)
+	}
+
+	if len(diff.AddedDevices) > 0 || len(diff.RemovedDevices) > 0 || len(diff.ChangedDevices) > 0 {
+		fmt.Fprintln(writer, "DEVICE\tCHANGE\tBEFORE\tAFTER\tDELTA")
+		for _, device := range diff.RemovedDevices {
+			fmt.Fprintf(writer, "%s\tremoved\tweight %g, %d partitions\t\t\n", device.Label(), device.Weight, device.Partitions)
+		}
+		for _, device := range diff.AddedDevices {
+			fmt.Fprintf(writer, "%s\tadded\t\tweight %g, %d partitions\t\n", device.Label(), device.Weight, device.Partitions)
+		}
+		for _, deviceDiff := range diff.ChangedDevices {
+			for _, change := range deviceDiff.Changes {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", deviceDiff.After.Label(), change.Field, change.Before, change.After, change.Delta)
+			}
+		}
+	}
+	must.Succeed(writer.Flush())
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package historycmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/backup"
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+)
+
+var (
+	builderFilename string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "history -b <file>",
+		Example: "  swift-ring-artisan history -b /etc/swift/object.builder",
+		Short:   "Lists the snapshots of a builder file.",
+		Long: `Lists the snapshots of a builder file in the backups directory next to it, oldest first.
+These are the snapshots apply and rollback save before changing a builder file, named like 1700000000.artisan.object.builder,
+as well as the backups swift-ring-builder saves after changing it, named like 1700000000.object.builder.
+A snapshot can be restored with the rollback command.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to list the snapshots of.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if builderFilename == "" {
+		logg.Fatal("--builder needs to be supplied and cannot be empty")
+	}
+
+	snapshots, err := backup.List(builderFilename)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SNAPSHOT\tTIME\tVERSION\tID\tDEVICES")
+	for _, snapshot := range snapshots {
+		ring := builderfile.File(snapshot.Filename)
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%d\n", filepath.Base(snapshot.Filename), snapshot.Time.Format(time.DateTime), ring.Version, ring.ID, ring.DeviceCount)
+	}
+	ring := builderfile.File(builderFilename)
+	fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%d\n", "(current)", "", ring.Version, ring.ID, ring.DeviceCount)
+	must.Succeed(writer.Flush())
+}
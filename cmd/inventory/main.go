@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package inventorycmd
+
+import (
+	"strconv"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/inventory"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+var (
+	baseSize       = 6 * misc.Terabyte
+	basePort       uint64
+	hostnameMeta   bool
+	inputFilename  string
+	outputFilename string
+	region         uint64
+	ringName       string
+	sourceFormat   string
+	zoneMapFlag    map[string]string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "inventory",
+		Example: "  swift-ring-artisan inventory --source netbox --input devices.json --ring object.builder --base-port 6000 --output rules.yaml",
+		Short:   "Generates a rule file from an inventory like a CMDB export.",
+		Long: `Generates a rule file from an inventory like a CMDB export.
+Racks are mapped to zones, servers to nodes and their disks to disk_count and disk_size.
+Supported sources are csv and json files with the fields name, rack, ip, port, disk_count and disk_size_tb
+as well as netbox exports of the devices API with the custom fields disk_count and disk_size_tb.
+Netbox exports need to contain all devices, e.g. fetched from /api/dcim/devices/?limit=0, paginated exports are rejected.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().VarP(&baseSize, "size", "s", "Base size of the disks which get a weight of 100, like 6TB or 14TiB. Bare numbers are TB.")
+	cmd.PersistentFlags().Uint64Var(&basePort, "base-port", 6000, "Port that is used by the nodes of the ring.")
+	cmd.PersistentFlags().BoolVar(&hostnameMeta, "hostname-meta", false, "Add the server names as hostname to the meta data of the nodes.")
+	cmd.PersistentFlags().StringVarP(&inputFilename, "input", "i", "", "Inventory file to read.")
+	cmd.PersistentFlags().StringVarP(&outputFilename, "output", "o", "", "Output file to write the rules to.")
+	cmd.PersistentFlags().Uint64Var(&region, "region", 1, "Region of the nodes.")
+	cmd.PersistentFlags().StringVar(&ringName, "ring", "object.builder", "Builder file name the rules are generated for.")
+	cmd.PersistentFlags().StringVar(&sourceFormat, "source", "csv", "Format of the inventory file. Can be csv, json or netbox.")
+	cmd.PersistentFlags().StringToStringVar(&zoneMapFlag, "zone-map", nil, "Assigns racks to zones like rack-a=1,rack-b=2. By default racks are numbered in alphabetical order.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if inputFilename == "" {
+		logg.Fatal("--input needs to be supplied and cannot be empty")
+	}
+
+	var source inventory.Source
+	switch sourceFormat {
+	case "csv":
+		source = inventory.CSVFile{Filename: inputFilename}
+	case "json":
+		source = inventory.JSONFile{Filename: inputFilename}
+	case "netbox":
+		source = inventory.NetBoxExport{Filename: inputFilename}
+	default:
+		logg.Fatal("--source needs to be set to csv, json OR netbox.")
+	}
+
+	zoneMap := make(map[string]uint64, len(zoneMapFlag))
+	for rack, zone := range zoneMapFlag {
+		var err error
+		zoneMap[rack], err = strconv.ParseUint(zone, 10, 64)
+		if err != nil {
+			logg.Fatal("--zone-map contains an invalid zone %q for rack %s", zone, rack)
+		}
+	}
+
+	servers, err := source.Servers()
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+
+	ringRules, err := inventory.BuildRules(servers, inventory.Options{
+		Region:       region,
+		BasePort:     basePort,
+		BaseSize:     baseSize,
+		ZoneMap:      zoneMap,
+		HostnameMeta: hostnameMeta,
+	})
+	if err != nil {
+		logg.Fatal("generating rules from %s failed: %s", inputFilename, err.Error())
+	}
+
+	file := map[string]rules.RingRules{ringName: ringRules}
+	dataYAML := must.Return(yaml.Marshal(file))
+	misc.WriteToStdoutOrFile(dataYAML, outputFilename)
+}
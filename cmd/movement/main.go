@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package movementcmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+var (
+	showDevices bool
+	usedSize    misc.ByteSize
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "movement <file> <file>",
+		Example: "  swift-ring-artisan movement object.builder.old object.builder --used-size 1.2PB",
+		Short:   "Counts the partition replicas which move between two versions of a builder file.",
+		Long: `Compares the partition assignment of two versions of a builder file and counts how many partition replicas moved,
+broken down by source and target region and zone and optionally device.
+With --used-size the moved data is estimated from the average size of a partition replica.`,
+		Args: cobra.ExactArgs(2),
+		Run:  run,
+	}
+	cmd.PersistentFlags().BoolVar(&showDevices, "devices", false, "Also break the movement down by source and target device.")
+	cmd.PersistentFlags().Var(&usedSize, "used-size", "Used capacity of all devices of the ring together like 1.2PB to estimate the moved data. Bare numbers are TB.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_ = cmd
+
+	movement, err := builderfile.CalculatePartitionMovement(builderfile.File(args[0]), builderfile.File(args[1]))
+	if err != nil {
+		logg.Fatal("%s: %s", args[1], err.Error())
+	}
+
+	fmt.Printf("%d of %d partition replicas moved (%.2f%%)\n", movement.MovedReplicas, movement.TotalReplicas, movement.MovedPercent())
+	if usedSize != 0 {
+		moved := misc.ByteSize(movement.EstimatedBytes(uint64(usedSize)))
+		fmt.Printf("Estimated data movement: %.2fTB\n", moved.TB())
+	}
+	if movement.MovedReplicas == 0 {
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printMoves := func(tier string, moves []builderfile.Moves) {
+		fmt.Fprintln(writer)
+		fmt.Fprintf(writer, "SOURCE %s\tTARGET %s\tREPLICAS\tSHARE\n", tier, tier)
+		for _, m := range moves {
+			fmt.Fprintf(writer, "%s\t%s\t%d\t%.2f%%\n", m.Source, m.Target, m.Replicas, float64(m.Replicas)/float64(movement.MovedReplicas)*100)
+		}
+	}
+	printMoves("REGION", movement.Regions)
+	printMoves("ZONE", movement.Zones)
+	if showDevices {
+		printMoves("DEVICE", movement.Devices)
+	}
+	must.Succeed(writer.Flush())
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package policiescmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/swiftconf"
+)
+
+var (
+	builderDirectory  string
+	swiftConfFilename string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "policies",
+		Example: "  swift-ring-artisan policies --swift-conf /etc/swift/swift.conf --directory /etc/swift",
+		Short:   "Lists the storage policies from swift.conf and validates their builder files.",
+		Long: `Lists the storage policies from swift.conf together with their replica counts, erasure coding parameters and builder files.
+If the builder file of a policy exists, its replica count is validated against the policy. If any validation fails the exit code is 1.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().StringVar(&builderDirectory, "directory", "/etc/swift", "Directory containing the builder files.")
+	cmd.PersistentFlags().StringVarP(&swiftConfFilename, "swift-conf", "s", "/etc/swift/swift.conf", "swift.conf file to read the storage policies from.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	policies := swiftconf.File(swiftConfFilename)
+
+	var errs []error
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "INDEX\tNAME\tTYPE\tDEFAULT\tREPLICAS\tEC PARAMETERS\tBUILDER")
+	for _, policy := range policies {
+		name := policy.Name
+		if len(policy.Aliases) > 0 {
+			name += " (" + strings.Join(policy.Aliases, ", ") + ")"
+		}
+		if policy.Deprecated {
+			name += " [deprecated]"
+		}
+
+		builderFilename := filepath.Join(builderDirectory, policy.BuilderFilename())
+		builderStatus := policy.BuilderFilename()
+		replicas := "-"
+		if expected, ok := policy.ExpectedReplicas(); ok {
+			replicas = strconv.FormatFloat(expected, 'g', -1, 64)
+		}
+
+		if _, err := os.Stat(builderFilename); err == nil {
+			ring := builderfile.File(builderFilename)
+			if _, ok := policy.ExpectedReplicas(); !ok {
+				replicas = strconv.FormatFloat(ring.Replicas, 'g', -1, 64)
+			}
+			if err := policy.CheckReplicas(ring); err != nil {
+				builderStatus += " (replica mismatch)"
+				errs = append(errs, err)
+			}
+		} else if errors.Is(err, os.ErrNotExist) {
+			builderStatus += " (missing)"
+		} else {
+			logg.Fatal(err.Error())
+		}
+
+		ecParameters := "-"
+		if policy.Type == swiftconf.PolicyTypeErasureCoding {
+			ecParameters = fmt.Sprintf("%s %d+%d", policy.ECType, policy.ECNumDataFragments, policy.ECNumParityFragments)
+			if policy.ECDuplicationFactor > 1 {
+				ecParameters += fmt.Sprintf(" x%d", policy.ECDuplicationFactor)
+			}
+			if policy.ECObjectSegmentSize != 0 {
+				ecParameters += fmt.Sprintf(", segment size %d", policy.ECObjectSegmentSize)
+			}
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%t\t%s\t%s\t%s\n", policy.Index, name, policy.Type, policy.Default, replicas, ecParameters, builderStatus)
+	}
+	must.Succeed(writer.Flush())
+
+	for _, err := range errs {
+		logg.Error(err.Error())
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package reconsynccmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/recon"
+	"github.com/sapcc/swift-ring-artisan/pkg/ruleedit"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+var (
+	builderFilename   string
+	diskUsageFilename string
+	outputFilename    string
+	propagate         bool
+	ruleFilename      string
+	unmountedFilename string
+	valuesFilename    string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "recon-sync -b <file> -r <file>",
+		Example: "  swift-ring-artisan recon-sync -b object.builder -r rules.yaml --unmounted unmounted.json --diskusage diskusage.json -o rules-new.yaml",
+		Short:   "Compares the disks reported by swift-recon with the rules and the ring.",
+		Long: `Compares the disks reported by swift-recon with the rules and the ring.
+The snapshots contain the output of the recon endpoints /recon/unmounted and /recon/diskusage per storage node, keyed by the node IP like {"10.114.1.202:6000": [{"device": "swift-02", "mounted": false}]}.
+Unmounted disks are proposed to be added to zero_weight_disks, so that they stay in the ring with weight 0 until they are replaced.
+Disks which are mounted again are proposed to be removed from zero_weight_disks and broken_disks.
+Disks which are expected but not reported by recon can only be detected with --diskusage because /recon/unmounted lists no mounted disks.
+If recon reports disks with higher numbers than disk_count, disk_count is proposed to be raised.
+With --output the rule file is written with all proposals applied to the nodes themselves, keeping its comments, anchors, aliases and key order like the rules command does.
+This requires --rule to be a single rule file which contains the ring and which does not start with "# swift-ring-artisan: template".`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to compare with the recon snapshots. Its name selects the rules of the ring.")
+	cmd.PersistentFlags().StringVar(&diskUsageFilename, "diskusage", "", "Snapshot of /recon/diskusage of all storage nodes.")
+	cmd.PersistentFlags().StringVarP(&outputFilename, "output", "o", "", "Output file to write the updated rules to. Can be the rule file itself.")
+	cmd.PersistentFlags().BoolVar(&propagate, "propagate", false, "With --output, change all nodes which share a changed node through an anchor.")
+	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file or directory of rule files to compare with the recon snapshots.")
+	cmd.PersistentFlags().StringVar(&valuesFilename, "values", "", "Values file for rule files which are Go templates. The values are available as .Values. If it is given, all rule files are rendered as templates.")
+	cmd.PersistentFlags().StringVar(&unmountedFilename, "unmounted", "", "Snapshot of /recon/unmounted of all storage nodes.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if builderFilename == "" {
+		logg.Fatal("--builder needs to be supplied and cannot be empty")
+	}
+	if ruleFilename == "" {
+		logg.Fatal("--rule needs to be supplied and cannot be empty")
+	}
+	if unmountedFilename == "" && diskUsageFilename == "" {
+		logg.Fatal("--unmounted or --diskusage needs to be supplied")
+	}
+
+	file := rules.ReadFile(ruleFilename, rules.ReadValues(valuesFilename))
+	builderBaseFilename := filepath.Base(builderFilename)
+	unresolvedRules, ok := file.Rings[builderBaseFilename]
+	if !ok {
+		logg.Fatal("%s is missing key for %s", ruleFilename, builderBaseFilename)
+	}
+	ringRules, err := unresolvedRules.Resolve(file.Profiles)
+	if err != nil {
+		logg.Fatal("Resolving %s failed: %s", ruleFilename, err.Error())
+	}
+
+	snapshot := make(recon.Snapshot)
+	for _, filename := range []string{unmountedFilename, diskUsageFilename} {
+		if filename == "" {
+			continue
+		}
+		s, err := recon.ReadSnapshot(filename)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+		snapshot = snapshot.Merge(s)
+	}
+
+	ring := builderfile.File(builderFilename)
+	report := recon.Sync(ringRules, ring, snapshot, diskUsageFilename != "")
+	if report.IsEmpty() {
+		logg.Info("recon, the rules and the ring agree with each other")
+		return
+	}
+
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.UnmountedDisks)) {
+		logg.Info("node %s: propose to add %s to zero_weight_disks because recon reports them as unmounted", nodeIP, strings.Join(report.UnmountedDisks[nodeIP], ", "))
+	}
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.RecoveredDisks)) {
+		logg.Info("node %s: propose to remove %s from zero_weight_disks and broken_disks because recon reports them as mounted", nodeIP, strings.Join(report.RecoveredDisks[nodeIP], ", "))
+	}
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.DiskCounts)) {
+		logg.Info("node %s: propose to raise disk_count to %d", nodeIP, report.DiskCounts[nodeIP])
+	}
+	for _, msg := range report.NotReported {
+		logg.Other("WARNING", "%s", msg)
+	}
+	for _, msg := range report.MissingFromRules {
+		logg.Other("WARNING", "%s", msg)
+	}
+
+	if outputFilename != "" {
+		editor, err := ruleedit.File(ruleFilename)
+		if err != nil {
+			logg.Fatal(err.Error())
+		}
+		if err := report.Apply(editor, builderBaseFilename, propagate); err != nil {
+			logg.Fatal("Applying the proposals to %s failed: %s", ruleFilename, err.Error())
+		}
+		misc.WriteToStdoutOrFile(must.Return(editor.Bytes()), outputFilename)
+		fmt.Printf("Wrote updated rules to %s\n", outputFilename)
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rollbackcmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sapcc/go-bits/errext"
+	"github.com/sapcc/go-bits/logg"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/backup"
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/lock"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+var (
+	assumeYes       bool
+	builderFilename string
+	snapshotName    string
+	waitForLock     bool
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "rollback -b <file> --to <snapshot>",
+		Example: "  swift-ring-artisan rollback -b /etc/swift/object.builder --to 1700000000.artisan.object.builder",
+		Short:   "Restores a snapshot of a builder file and rewrites the ring.",
+		Long: `Restores a snapshot of a builder file which is listed by the history command and rewrites the ring file with swift-ring-builder write_ring.
+The snapshot can be given by its name or its unix timestamp. A snapshot of the current builder file is saved first, so that the rollback can be undone.
+The builder file is locked like in the apply command, so that a rollback cannot run concurrently with apply.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().StringVarP(&builderFilename, "builder", "b", "", "Builder file to restore.")
+	cmd.PersistentFlags().StringVar(&snapshotName, "to", "", "Name or unix timestamp of the snapshot to restore.")
+	cmd.PersistentFlags().BoolVar(&waitForLock, "wait", false, "Wait until another run holding the lock of the builder file finishes instead of failing.")
+	cmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if builderFilename == "" {
+		logg.Fatal("--builder needs to be supplied and cannot be empty")
+	}
+	if snapshotName == "" {
+		logg.Fatal("--to needs to be supplied and cannot be empty")
+	}
+
+	l, err := lock.Acquire(builderFilename, waitForLock)
+	if errext.IsOfType[lock.ErrLocked](err) {
+		logg.Fatal("%s, use --wait to wait until it is released", err.Error())
+	} else if err != nil {
+		logg.Fatal(err.Error())
+	}
+	defer l.Release() //nolint:errcheck // the lock is released on exit anyway
+
+	snapshot, err := backup.Find(builderFilename, snapshotName)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+
+	current := builderfile.File(builderFilename)
+	restored := builderfile.File(snapshot.Filename)
+	question := fmt.Sprintf("Do you want to roll back %s from version %d to version %d with %d devices from %s?",
+		builderFilename, current.Version, restored.Version, restored.DeviceCount, snapshot.Time.Format(time.DateTime))
+	if !assumeYes && !misc.AskConfirmation(question) {
+		logg.Fatal("Aborting")
+	}
+
+	snapshotFilename, err := backup.Create(builderFilename, time.Now())
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	logg.Info("Saved snapshot of %s to %s", builderFilename, snapshotFilename)
+
+	err = backup.Restore(builderFilename, snapshot)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	logg.Info("Restored %s from %s", builderFilename, snapshot.Filename)
+
+	command := exec.Command("swift-ring-builder", builderFilename, "write_ring")
+	stdout, err := command.Output()
+	for line := range strings.SplitSeq(string(stdout), "\n") {
+		if line != "" {
+			logg.Info(line)
+		}
+	}
+	if err != nil {
+		logg.Fatal("Command %q failed: %s", strings.Join(command.Args, " "), err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rulescmd
+
+import (
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/ruleedit"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+var (
+	diskCount    uint64
+	diskNames    []string
+	diskSize     misc.ByteSize
+	meta         map[string]string
+	nodeIP       string
+	port         uint64
+	propagate    bool
+	ringName     string
+	ruleFilename string
+	weight       float64
+	zone         uint64
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Changes a rule file in place.",
+		Long: `Changes a rule file in place while keeping its comments, anchors, aliases and key order.
+A node which refers to another node through an alias gets a merge key with the changed values, so that the other nodes are not affected.
+Nodes which are shared with other nodes through an anchor are only changed together with them if --propagate is given.
+Rule files which start with "# swift-ring-artisan: template" cannot be changed because the template source would be lost.
+Other rule files are changed as they are without rendering them, even if they are rendered as templates with --values elsewhere.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help() //nolint:errcheck
+		},
+	}
+	cmd.PersistentFlags().StringVar(&nodeIP, "ip", "", "IP of the node to change.")
+	cmd.PersistentFlags().StringVar(&ringName, "ring", "", "Builder file name of the ring to change. Can be omitted if the rule file contains only one ring.")
+	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file to change.")
+
+	addNodeCmd := &cobra.Command{
+		Use:     "add-node",
+		Example: "  swift-ring-artisan rules add-node --rule rules.yaml --zone 2 --ip 10.0.0.5 --disk-count 12 --disk-size 14TiB",
+		Short:   "Adds a node to a zone.",
+		Args:    cobra.NoArgs,
+		Run:     runAddNode,
+	}
+	addNodeCmd.Flags().Uint64Var(&diskCount, "disk-count", 0, "Number of disks of the node.")
+	addNodeCmd.Flags().Var(&diskSize, "disk-size", "Size of the disks of the node like 6TB or 14TiB.")
+	addNodeCmd.Flags().StringToStringVar(&meta, "meta", nil, "Meta data of the node like hostname=node01.")
+	addNodeCmd.Flags().Uint64Var(&port, "port", 0, "Port of the node if it differs from the base port.")
+	addNodeCmd.Flags().Uint64Var(&zone, "zone", 0, "Zone to add the node to.")
+	cmd.AddCommand(addNodeCmd)
+
+	markBrokenCmd := &cobra.Command{
+		Use:     "mark-broken",
+		Example: "  swift-ring-artisan rules mark-broken --rule rules.yaml --ip 10.0.0.5 --disk swift-03",
+		Short:   "Marks disks of a node as broken.",
+		Args:    cobra.NoArgs,
+		Run:     runMarkBroken,
+	}
+	markBrokenCmd.Flags().StringSliceVar(&diskNames, "disk", nil, "Disks to mark as broken like swift-03. Can be given multiple times.")
+	markBrokenCmd.Flags().BoolVar(&propagate, "propagate", false, "Change all nodes which share the node through an anchor.")
+	cmd.AddCommand(markBrokenCmd)
+
+	setWeightCmd := &cobra.Command{
+		Use:     "set-weight",
+		Example: "  swift-ring-artisan rules set-weight --rule rules.yaml --ip 10.0.0.5 --weight 0",
+		Short:   "Sets an explicit weight for the disks of a node.",
+		Args:    cobra.NoArgs,
+		Run:     runSetWeight,
+	}
+	setWeightCmd.Flags().BoolVar(&propagate, "propagate", false, "Change all nodes which share the node through an anchor.")
+	setWeightCmd.Flags().Float64Var(&weight, "weight", 0, "Weight of the disks.")
+	must.Succeed(setWeightCmd.MarkFlagRequired("weight"))
+	cmd.AddCommand(setWeightCmd)
+
+	parent.AddCommand(cmd)
+}
+
+// loadRules reads the rule file and determines the ring to change
+func loadRules() (*ruleedit.Editor, string) {
+	if ruleFilename == "" {
+		logg.Fatal("--rule needs to be supplied and cannot be empty")
+	}
+	if nodeIP == "" {
+		logg.Fatal("--ip needs to be supplied and cannot be empty")
+	}
+
+	editor, err := ruleedit.File(ruleFilename)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+
+	if ringName != "" {
+		return editor, ringName
+	}
+	ringNames := editor.RingNames()
+	if len(ringNames) != 1 {
+		logg.Fatal("--ring needs to be supplied because the rule file contains %d rings", len(ringNames))
+	}
+	return editor, ringNames[0]
+}
+
+func saveRules(editor *ruleedit.Editor) {
+	misc.WriteToStdoutOrFile(must.Return(editor.Bytes()), ruleFilename)
+}
+
+func runAddNode(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	editor, ringName := loadRules()
+	if zone == 0 {
+		logg.Fatal("--zone needs to be supplied and cannot be 0")
+	}
+	if diskCount == 0 {
+		logg.Fatal("--disk-count needs to be supplied and cannot be 0")
+	}
+
+	nodeRules := rules.NodeRules{
+		Port:      port,
+		DiskCount: diskCount,
+		DiskSize:  diskSize,
+	}
+	if len(meta) > 0 {
+		nodeRules.Meta = &meta
+	}
+	if err := editor.AddNode(ringName, zone, nodeIP, nodeRules); err != nil {
+		logg.Fatal(err.Error())
+	}
+	saveRules(editor)
+}
+
+func runMarkBroken(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	editor, ringName := loadRules()
+	if len(diskNames) == 0 {
+		logg.Fatal("--disk needs to be supplied and cannot be empty")
+	}
+
+	if err := editor.MarkBroken(ringName, nodeIP, diskNames, propagate); err != nil {
+		logg.Fatal(err.Error())
+	}
+	saveRules(editor)
+}
+
+func runSetWeight(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	editor, ringName := loadRules()
+	if err := editor.SetWeight(ringName, nodeIP, weight, propagate); err != nil {
+		logg.Fatal(err.Error())
+	}
+	saveRules(editor)
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package weightscmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
+	"github.com/spf13/cobra"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+var (
+	ringName       string
+	ruleFilename   string
+	valuesFilename string
+)
+
+// AddCommandTo adds a command to cobra.Command
+func AddCommandTo(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:     "weights",
+		Example: "  swift-ring-artisan weights --rule rules.yaml --ring object.builder",
+		Short:   "Explains how the weights of the disks are calculated.",
+		Long: `Lists the desired weight of the disks of every node in the rule file together with an explanation how it was calculated.
+The weight policy of a ring is selected with weight_policy and can be one of:
+  relative (default)  disk size relative to base_size, a disk with the base size has a weight of 100
+  absolute            disk size in GB (10^9 bytes), rounded down
+  usable              like relative but without the reserve_percent of the disk
+  reported            reported_weight of the node, e.g. from a hardware inventory
+A node can override the weight policy of its ring, but absolute cannot be mixed with relative or usable within a ring.`,
+		Args: cobra.NoArgs,
+		Run:  run,
+	}
+	cmd.PersistentFlags().StringVar(&ringName, "ring", "", "Only explain the weights of this ring.")
+	cmd.PersistentFlags().StringVarP(&ruleFilename, "rule", "r", "", "Rule file or directory of rule files to calculate the weights from.")
+	cmd.PersistentFlags().StringVar(&valuesFilename, "values", "", "Values file for rule files which are Go templates. The values are available as .Values. If it is given, all rule files are rendered as templates.")
+	parent.AddCommand(cmd)
+}
+
+func run(cmd *cobra.Command, args []string) {
+	_, _ = cmd, args
+
+	if ruleFilename == "" {
+		logg.Fatal("--rule needs to be supplied and cannot be empty")
+	}
+
+	file := rules.Load(ruleFilename, rules.ReadValues(valuesFilename))
+
+	ringNames := rules.GetRingNames(file)
+	if ringName != "" {
+		if _, ok := file[ringName]; !ok {
+			logg.Fatal("The rule file does not contain rules for %s", ringName)
+		}
+		ringNames = []string{ringName}
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "RING\tZONE\tNODE\tWEIGHT\tEXPLANATION")
+	for _, name := range ringNames {
+		explanations, err := file[name].ExplainWeights()
+		if err != nil {
+			logg.Fatal("%s: %s", name, err.Error())
+		}
+		for _, explanation := range explanations {
+			fmt.Fprintf(writer, "%s\t%d\t%s\t%g\t%s\n", name, explanation.Zone, explanation.NodeIP, explanation.Weight, explanation.Explanation)
+		}
+	}
+	must.Succeed(writer.Flush())
+}
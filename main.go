@@ -13,7 +13,17 @@ import (
 
 	applycmd "github.com/sapcc/swift-ring-artisan/cmd/apply"
 	convertcmd "github.com/sapcc/swift-ring-artisan/cmd/convert"
+	diffcmd "github.com/sapcc/swift-ring-artisan/cmd/diff"
+	diffbuilderscmd "github.com/sapcc/swift-ring-artisan/cmd/diffbuilders"
+	historycmd "github.com/sapcc/swift-ring-artisan/cmd/history"
+	inventorycmd "github.com/sapcc/swift-ring-artisan/cmd/inventory"
+	movementcmd "github.com/sapcc/swift-ring-artisan/cmd/movement"
 	parsecmd "github.com/sapcc/swift-ring-artisan/cmd/parse"
+	policiescmd "github.com/sapcc/swift-ring-artisan/cmd/policies"
+	reconsynccmd "github.com/sapcc/swift-ring-artisan/cmd/reconsync"
+	rollbackcmd "github.com/sapcc/swift-ring-artisan/cmd/rollback"
+	rulescmd "github.com/sapcc/swift-ring-artisan/cmd/rules"
+	weightscmd "github.com/sapcc/swift-ring-artisan/cmd/weights"
 )
 
 // ParseBool is like strconv.ParseBool() but doesn't return any error.
@@ -38,7 +48,17 @@ func main() {
 
 	applycmd.AddCommandTo(rootCmd)
 	convertcmd.AddCommandTo(rootCmd)
+	diffcmd.AddCommandTo(rootCmd)
+	diffbuilderscmd.AddCommandTo(rootCmd)
+	historycmd.AddCommandTo(rootCmd)
+	inventorycmd.AddCommandTo(rootCmd)
+	movementcmd.AddCommandTo(rootCmd)
 	parsecmd.AddCommandTo(rootCmd)
+	policiescmd.AddCommandTo(rootCmd)
+	reconsynccmd.AddCommandTo(rootCmd)
+	rollbackcmd.AddCommandTo(rootCmd)
+	rulescmd.AddCommandTo(rootCmd)
+	weightscmd.AddCommandTo(rootCmd)
 
 	must.Succeed(rootCmd.Execute())
 }
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshot is a copy of a builder file in the backups directory next to it.
+// swift-ring-builder stores its own backups there named like "1700000000.object.builder" after it changed a builder file.
+// Snapshots created by Create are named like "1700000000.artisan.object.builder" instead, so that a backup which
+// swift-ring-builder writes within the same second cannot replace them.
+type Snapshot struct {
+	Filename string
+	Time     time.Time
+	// Artisan is true for snapshots created by Create and false for backups of swift-ring-builder
+	Artisan bool
+}
+
+// artisanInfix distinguishes the snapshots created by Create from the backups of swift-ring-builder
+const artisanInfix = ".artisan"
+
+// Dir returns the backups directory of a builder file like swift-ring-builder uses it
+func Dir(builderFilename string) string {
+	return filepath.Join(filepath.Dir(builderFilename), "backups")
+}
+
+// snapshotFilename returns the name of a snapshot like "1700000000.artisan.object.builder"
+func snapshotFilename(builderFilename string, now time.Time) string {
+	return filepath.Join(Dir(builderFilename), fmt.Sprintf("%d%s.%s", now.Unix(), artisanInfix, filepath.Base(builderFilename)))
+}
+
+// copyFile copies src to dst. If exclusive is set, dst must not exist yet.
+func copyFile(src, dst string, exclusive bool) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	out, err := os.OpenFile(dst, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// Create stores a snapshot of the builder file in its backups directory and returns the filename of the snapshot
+func Create(builderFilename string, now time.Time) (string, error) {
+	if err := os.MkdirAll(Dir(builderFilename), 0755); err != nil {
+		return "", err
+	}
+	filename := snapshotFilename(builderFilename, now)
+	if err := copyFile(builderFilename, filename, true); err != nil {
+		return "", fmt.Errorf("creating snapshot of %s failed: %w", builderFilename, err)
+	}
+	return filename, nil
+}
+
+// List returns the snapshots of the builder file ordered from the oldest to the newest
+func List(builderFilename string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(Dir(builderFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	suffix := "." + filepath.Base(builderFilename)
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		timestamp, ok := strings.CutSuffix(entry.Name(), suffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		timestamp, isArtisan := strings.CutSuffix(timestamp, artisanInfix)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Filename: filepath.Join(Dir(builderFilename), entry.Name()),
+			Time:     time.Unix(seconds, 0),
+			Artisan:  isArtisan,
+		})
+	}
+
+	// a snapshot of apply is taken before the changes which swift-ring-builder backs up within the same second
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Time.Equal(snapshots[j].Time) {
+			return snapshots[i].Time.Before(snapshots[j].Time)
+		}
+		return snapshots[i].Artisan && !snapshots[j].Artisan
+	})
+	return snapshots, nil
+}
+
+// Find returns the snapshot of the builder file which matches either its filename, with or without directory, or its unix timestamp.
+// A timestamp which matches a snapshot of apply and a backup of swift-ring-builder is rejected as ambiguous.
+func Find(builderFilename, name string) (Snapshot, error) {
+	snapshots, err := List(builderFilename)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var matches []Snapshot
+	for _, snapshot := range snapshots {
+		if name == snapshot.Filename || name == filepath.Base(snapshot.Filename) || name == strconv.FormatInt(snapshot.Time.Unix(), 10) {
+			matches = append(matches, snapshot)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("%s has no snapshot %q", builderFilename, name)
+	case 1:
+		return matches[0], nil
+	default:
+		var names []string
+		for _, snapshot := range matches {
+			names = append(names, filepath.Base(snapshot.Filename))
+		}
+		return Snapshot{}, fmt.Errorf("%q matches multiple snapshots of %s, use one of their names: %s", name, builderFilename, strings.Join(names, ", "))
+	}
+}
+
+// Restore replaces the builder file with the snapshot.
+// The snapshot is copied next to the builder file first and then renamed, so that the builder file is never half written.
+func Restore(builderFilename string, snapshot Snapshot) error {
+	tmpFilename := builderFilename + ".restore"
+	if err := copyFile(snapshot.Filename, tmpFilename, false); err != nil {
+		return fmt.Errorf("restoring %s failed: %w", snapshot.Filename, err)
+	}
+	if err := os.Rename(tmpFilename, builderFilename); err != nil {
+		os.Remove(tmpFilename) //nolint:errcheck // the error of the rename is more relevant
+		return fmt.Errorf("restoring %s failed: %w", snapshot.Filename, err)
+	}
+	return nil
+}
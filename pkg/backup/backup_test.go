@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+)
+
+func TestCreateListRestore(t *testing.T) {
+	dir := t.TempDir()
+	builderFilename := filepath.Join(dir, "object.builder")
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 1"), 0644))
+
+	first, err := Create(builderFilename, time.Unix(1700000000, 0))
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, first, filepath.Join(dir, "backups", "1700000000.artisan.object.builder"))
+
+	_, err = Create(builderFilename, time.Unix(1700000000, 0))
+	assert.ErrEqual(t, err, "creating snapshot of "+builderFilename+" failed: open "+first+": file exists")
+
+	must.SucceedT(t, os.WriteFile(builderFilename, []byte("version 2"), 0644))
+	second, err := Create(builderFilename, time.Unix(1700000100, 0))
+	assert.ErrEqual(t, err, nil)
+
+	// backups of other rings and of the ring files written by swift-ring-builder are ignored
+	must.SucceedT(t, os.WriteFile(filepath.Join(dir, "backups", "1700000050.account.builder"), nil, 0644))
+	must.SucceedT(t, os.WriteFile(filepath.Join(dir, "backups", "1700000050.object.ring.gz"), nil, 0644))
+
+	// swift-ring-builder backs up the changed builder file within the same second as the snapshot of apply
+	rebalanced := filepath.Join(dir, "backups", "1700000100.object.builder")
+	must.SucceedT(t, os.WriteFile(rebalanced, []byte("version 3"), 0644))
+
+	snapshots, err := List(builderFilename)
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "snapshots", snapshots, []Snapshot{
+		{Filename: first, Time: time.Unix(1700000000, 0), Artisan: true},
+		{Filename: second, Time: time.Unix(1700000100, 0), Artisan: true},
+		{Filename: rebalanced, Time: time.Unix(1700000100, 0)},
+	})
+
+	snapshot, err := Find(builderFilename, "1700000000")
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, snapshot.Filename, first)
+	_, err = Find(builderFilename, "1700000100")
+	assert.ErrEqual(t, err, `"1700000100" matches multiple snapshots of `+builderFilename+`, use one of their names: 1700000100.artisan.object.builder, 1700000100.object.builder`)
+	snapshot, err = Find(builderFilename, "1700000100.object.builder")
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, snapshot.Filename, rebalanced)
+	snapshot, err = Find(builderFilename, first)
+	assert.ErrEqual(t, err, nil)
+	_, err = Find(builderFilename, "1700000050.account.builder")
+	assert.ErrEqual(t, err, builderFilename+` has no snapshot "1700000050.account.builder"`)
+
+	assert.ErrEqual(t, Restore(builderFilename, snapshot), nil)
+	assert.Equal(t, string(must.ReturnT(os.ReadFile(builderFilename))(t)), "version 1")
+}
+
+func TestListWithoutBackups(t *testing.T) {
+	snapshots, err := List(filepath.Join(t.TempDir(), "object.builder"))
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "snapshots", snapshots, []Snapshot(nil))
+}
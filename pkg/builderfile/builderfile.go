@@ -19,6 +19,15 @@ import (
 
 // File takes a path to a builder file. It tries to unpickle it
 func File(builderFilename string) RingInfo {
+	ring, err := Read(builderFilename)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	return ring
+}
+
+// Read unpickles a builder file like File but returns an error instead of stopping the process
+func Read(builderFilename string) (RingInfo, error) {
 	// // generate with ./unpickle.sh
 	// cmd := exec.Command("python3", "-c", "'import json;import pickle;import sys;d=pickle.load(open(sys.argv[-1],\"rb\"));d[\"_dispersion_graph\"]=None;d[\"_replica2part2dev\"]=None;d[\"_last_part_moves\"]=None;print(json.dumps(d));'", builderFilename)
 	// stdout, err := cmd.Output()
@@ -37,7 +46,10 @@ func File(builderFilename string) RingInfo {
 	// 	logg.Fatal(err.Error())
 	// }
 
-	pickleData := decodeBuilderFile(builderFilename)
+	pickleData, err := decodeBuilderFile(builderFilename)
+	if err != nil {
+		return RingInfo{}, err
+	}
 	ring := RingInfo{
 		ID:                    pickleData.ID,
 		Version:               pickleData.Version,
@@ -45,10 +57,18 @@ func File(builderFilename string) RingInfo {
 		DeviceCount:           uint64(len(pickleData.Devices)),
 		Dispersion:            pickleData.Dispersion,
 		Partitions:            pickleData.Partitions,
-		Regions:               pickleData.Devices[0].Region, // FIXME: make multi region aware
+		PartPower:             pickleData.PartPower,
+		NextPartPower:         pickleData.NextPartPower,
+		Regions:               countRegions(pickleData.Devices),
 		Replicas:              pickleData.Replicas,
 		OverloadFactorDecimal: pickleData.Overload,
+		ReassignedCooldown:    pickleData.MinPartHours,
+		Replica2Part2Dev:      pickleData.Replica2Part2Dev,
 	}
+	// same calculation as min_part_seconds_left in swift's RingBuilder
+	elapsed := time.Since(time.Unix(int64(pickleData.LastPartMovesEpoch), 0))
+	remaining := max(time.Duration(pickleData.MinPartHours)*time.Hour-elapsed, 0).Truncate(time.Second)
+	ring.ReassignedRemaining = reassignedRemainingBase.Add(remaining)
 	// round to two decimal places to match the cli output
 	ring.Dispersion = math.Round(ring.Dispersion*100) / 100
 
@@ -58,31 +78,48 @@ func File(builderFilename string) RingInfo {
 	if err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
 			logg.Debug("Did not find swift-ring-builder in PATH, skipping consistency check")
-			return ring
+			return ring, nil
 		}
-		logg.Fatal("while running swift-ring-builder: " + err.Error())
+		return RingInfo{}, fmt.Errorf("while running swift-ring-builder: %w", err)
 	}
 
-	ringParsed := Input(bytes.NewReader(stdout))
+	ringParsed, err := Parse(bytes.NewReader(stdout))
+	if err != nil {
+		return RingInfo{}, err
+	}
 	// overwrite some data that the parser method but not the pickler method extracts
 	ringParsed.Balance = 0
 	ringParsed.FileName = ""
-	ringParsed.ReassignedCooldown = 0
-	ringParsed.ReassignedRemaining = time.Time{}
+	// the remaining time is calculated at slightly different points in time
+	ringParsed.ReassignedRemaining = ring.ReassignedRemaining
 	ringParsed.Zones = 0
 	ringParsed.OverloadFactorPercent = 0 // rely on OverloadFactorDecimal
+	// the part power is only printed while it is being increased
+	ringParsed.PartPower = ring.PartPower
 
 	sort.Slice(ringParsed.Devices, func(i, j int) bool {
 		return ringParsed.Devices[i].ID < ringParsed.Devices[j].ID
 	})
 
-	equal := reflect.DeepEqual(ringParsed, ring)
+	// the partition assignment is not printed and too large to be part of the diff
+	ringPickled := ring
+	ringPickled.Replica2Part2Dev = nil
+
+	equal := reflect.DeepEqual(ringParsed, ringPickled)
 	if !equal {
 		dmp := diffmatchpatch.New()
-		diffs := dmp.DiffMain(fmt.Sprintf("%+v\n", ringParsed), fmt.Sprintf("%+v\n", ring), false)
-		logg.Info("Pickle parsed output and swift-ring-builder output are not equal. What is going on here?!")
-		logg.Fatal(dmp.DiffPrettyText(diffs))
+		diffs := dmp.DiffMain(fmt.Sprintf("%+v\n", ringParsed), fmt.Sprintf("%+v\n", ringPickled), false)
+		return RingInfo{}, fmt.Errorf("pickle parsed output and swift-ring-builder output of %s are not equal:\n%s", builderFilename, dmp.DiffPrettyText(diffs))
 	}
 
-	return ring
+	return ring, nil
+}
+
+// countRegions returns the number of distinct regions like swift-ring-builder does
+func countRegions(devices []DeviceInfo) uint64 {
+	regions := make(map[uint64]struct{})
+	for _, device := range devices {
+		regions[device.Region] = struct{}{}
+	}
+	return uint64(len(regions))
 }
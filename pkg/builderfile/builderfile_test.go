@@ -5,10 +5,13 @@ package builderfile
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sapcc/go-bits/assert"
 	"github.com/sapcc/go-bits/logg"
+	"github.com/sapcc/go-bits/must"
 
 	"github.com/sapcc/swift-ring-artisan/pkg/misc"
 )
@@ -40,3 +43,17 @@ func TestParse2(t *testing.T) {
 	metaData := Input(input)
 	assert.DeepEqual(t, "parsing", metaData, expected)
 }
+
+func TestMinPartSecondsLeft(t *testing.T) {
+	input := must.Return(os.ReadFile("../../testing/builder-output-1.txt"))
+
+	metaData := Input(strings.NewReader(string(input)))
+	assert.Equal(t, metaData.MinPartSecondsLeft(), 0)
+
+	remaining := strings.Replace(string(input), "(0:00:00 remaining)", "(1:30:05 remaining)", 1)
+	metaData = Input(strings.NewReader(remaining))
+	assert.Equal(t, metaData.ReassignedCooldown, 24)
+	assert.Equal(t, metaData.MinPartSecondsLeft(), time.Hour+30*time.Minute+5*time.Second)
+
+	assert.Equal(t, RingInfo{}.MinPartSecondsLeft(), 0)
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// maxBalance is the balance swift-ring-builder reports for devices with partitions but without weight
+const maxBalance = 999.99
+
+// Change is a single value which differs between two versions of a ring.
+// Delta is only set for numeric values.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+	Delta  string
+}
+
+// DeviceDiff contains the changes of a device which exists in both versions of a ring
+type DeviceDiff struct {
+	Before  DeviceInfo
+	After   DeviceInfo
+	Changes []Change
+}
+
+// RingDiff contains the differences between two versions of a ring
+type RingDiff struct {
+	Changes        []Change
+	AddedDevices   []DeviceInfo
+	RemovedDevices []DeviceInfo
+	ChangedDevices []DeviceDiff
+}
+
+// IsEmpty returns true if both versions of the ring are the same
+func (diff RingDiff) IsEmpty() bool {
+	return len(diff.Changes) == 0 && len(diff.AddedDevices) == 0 && len(diff.RemovedDevices) == 0 && len(diff.ChangedDevices) == 0
+}
+
+// Label returns a short description of the device like "z1 10.114.1.202:6001/swift-01"
+func (device DeviceInfo) Label() string {
+	return fmt.Sprintf("z%d %s/%s", device.Zone, device.IPAddressPort(), device.Name)
+}
+
+// DeviceBalances returns the balance of every device by its ID and the balance of the ring which is the highest
+// absolute device balance. The balance is the percentage of partitions a device has more or less than its weight asks for.
+func (ring RingInfo) DeviceBalances() (balances map[uint64]float64, ringBalance float64) {
+	var totalWeight float64
+	for _, device := range ring.Devices {
+		totalWeight += device.Weight
+	}
+
+	balances = make(map[uint64]float64, len(ring.Devices))
+	for _, device := range ring.Devices {
+		var balance float64
+		switch {
+		case device.Weight == 0 && device.Partitions > 0:
+			balance = maxBalance
+		case device.Weight != 0:
+			wantedPartitions := float64(ring.Partitions) * ring.Replicas * device.Weight / totalWeight
+			balance = 100*float64(device.Partitions)/wantedPartitions - 100
+		}
+		// round to two decimal places to match the cli output
+		balance = math.Round(balance*100) / 100
+		balances[device.ID] = balance
+		ringBalance = max(ringBalance, math.Abs(balance))
+	}
+	return balances, ringBalance
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func formatMeta(meta *map[string]string) string {
+	if meta == nil {
+		return "{}"
+	}
+	return fmt.Sprintf("%v", *meta)
+}
+
+// compareFloat returns a change with the difference of both values if they are not equal
+func compareFloat(field string, before, after float64) []Change {
+	if math.Abs(after-before) <= 0.000001 {
+		return nil
+	}
+	return []Change{{
+		Field:  field,
+		Before: formatFloat(before),
+		After:  formatFloat(after),
+		Delta:  fmt.Sprintf("%+g", math.Round((after-before)*100)/100),
+	}}
+}
+
+func compareUint(field string, before, after uint64) []Change {
+	return compareFloat(field, float64(before), float64(after))
+}
+
+// Diff compares two versions of a ring. Devices are matched by their IP and name.
+func Diff(before, after RingInfo) RingDiff {
+	beforeBalances, beforeBalance := before.DeviceBalances()
+	afterBalances, afterBalance := after.DeviceBalances()
+
+	var diff RingDiff
+	if before.ID != after.ID {
+		diff.Changes = append(diff.Changes, Change{Field: "id", Before: before.ID, After: after.ID})
+	}
+	diff.Changes = append(diff.Changes, compareUint("version", before.Version, after.Version)...)
+	diff.Changes = append(diff.Changes, compareUint("partitions", before.Partitions, after.Partitions)...)
+	diff.Changes = append(diff.Changes, compareFloat("replicas", before.Replicas, after.Replicas)...)
+	diff.Changes = append(diff.Changes, compareUint("min_part_hours", before.ReassignedCooldown, after.ReassignedCooldown)...)
+	diff.Changes = append(diff.Changes, compareFloat("overload", before.OverloadFactorDecimal, after.OverloadFactorDecimal)...)
+	diff.Changes = append(diff.Changes, compareFloat("balance", beforeBalance, afterBalance)...)
+	diff.Changes = append(diff.Changes, compareFloat("dispersion", before.Dispersion, after.Dispersion)...)
+	diff.Changes = append(diff.Changes, compareUint("devices", uint64(len(before.Devices)), uint64(len(after.Devices)))...)
+
+	type deviceKey struct {
+		NodeIP string
+		Name   string
+	}
+	beforeDevices := make(map[deviceKey]DeviceInfo, len(before.Devices))
+	for _, device := range before.Devices {
+		beforeDevices[deviceKey{device.NodeIP, device.Name}] = device
+	}
+
+	matched := make(map[deviceKey]bool)
+	for _, device := range after.Devices {
+		key := deviceKey{device.NodeIP, device.Name}
+		old, ok := beforeDevices[key]
+		if !ok {
+			diff.AddedDevices = append(diff.AddedDevices, device)
+			continue
+		}
+		matched[key] = true
+
+		var changes []Change
+		if old.Zone != device.Zone {
+			changes = append(changes, Change{Field: "zone", Before: strconv.FormatUint(old.Zone, 10), After: strconv.FormatUint(device.Zone, 10)})
+		}
+		if old.Port != device.Port {
+			changes = append(changes, Change{Field: "port", Before: strconv.FormatUint(old.Port, 10), After: strconv.FormatUint(device.Port, 10)})
+		}
+		changes = append(changes, compareFloat("weight", old.Weight, device.Weight)...)
+		changes = append(changes, compareUint("partitions", old.Partitions, device.Partitions)...)
+		changes = append(changes, compareFloat("balance", beforeBalances[old.ID], afterBalances[device.ID])...)
+		if !reflect.DeepEqual(old.Meta, device.Meta) {
+			changes = append(changes, Change{Field: "meta", Before: formatMeta(old.Meta), After: formatMeta(device.Meta)})
+		}
+		if len(changes) > 0 {
+			diff.ChangedDevices = append(diff.ChangedDevices, DeviceDiff{Before: old, After: device, Changes: changes})
+		}
+	}
+
+	for _, device := range before.Devices {
+		if !matched[deviceKey{device.NodeIP, device.Name}] {
+			diff.RemovedDevices = append(diff.RemovedDevices, device)
+		}
+	}
+
+	sort.Slice(diff.AddedDevices, func(i, j int) bool { return diff.AddedDevices[i].ID < diff.AddedDevices[j].ID })
+	sort.Slice(diff.RemovedDevices, func(i, j int) bool { return diff.RemovedDevices[i].ID < diff.RemovedDevices[j].ID })
+	sort.Slice(diff.ChangedDevices, func(i, j int) bool { return diff.ChangedDevices[i].After.ID < diff.ChangedDevices[j].After.ID })
+
+	return diff
+}
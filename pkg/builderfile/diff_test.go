@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestDiffSame(t *testing.T) {
+	var ring RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &ring)
+
+	diff := Diff(ring, ring)
+	assert.Equal(t, diff.IsEmpty(), true)
+}
+
+func TestDiff(t *testing.T) {
+	var before, after RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &before)
+	misc.ReadYAML("../../testing/builder-output-diff.yaml", &after)
+
+	diff := Diff(before, after)
+	assert.DeepEqual(t, "ring changes", diff.Changes, []Change{
+		{Field: "version", Before: "7", After: "9", Delta: "+2"},
+		{Field: "overload", Before: "0", After: "0.1", Delta: "+0.1"},
+		{Field: "balance", Before: "0", After: "0.2", Delta: "+0.2"},
+		{Field: "dispersion", Before: "0", After: "1.5", Delta: "+1.5"},
+	})
+	assert.DeepEqual(t, "added devices", diff.AddedDevices, []DeviceInfo{after.Devices[5]})
+	assert.DeepEqual(t, "removed devices", diff.RemovedDevices, []DeviceInfo{before.Devices[5]})
+
+	assert.Equal(t, len(diff.ChangedDevices), 5)
+	assert.DeepEqual(t, "changes of swift-01", diff.ChangedDevices[0].Changes, []Change{
+		{Field: "weight", Before: "100", After: "200", Delta: "+100"},
+		{Field: "partitions", Before: "512", After: "878", Delta: "+366"},
+		{Field: "balance", Before: "0", After: "0.03", Delta: "+0.03"},
+	})
+	assert.DeepEqual(t, "changes of swift-02", diff.ChangedDevices[1].Changes, []Change{
+		{Field: "partitions", Before: "512", After: "439", Delta: "-73"},
+		{Field: "balance", Before: "0", After: "0.03", Delta: "+0.03"},
+		{Field: "meta", Before: "{}", After: "map[host:swift-node-a]"},
+	})
+	assert.Equal(t, diff.ChangedDevices[1].After.Label(), "z1 10.114.1.202:6001/swift-02")
+}
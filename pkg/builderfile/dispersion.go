@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TierDispersion is a tier of the ring like a zone or a server which holds more replicas of some partitions
+// than it should to keep the replicas as unique as possible.
+type TierDispersion struct {
+	Tier       string
+	Partitions uint64
+}
+
+// tiers returns the region, zone, server and device tier of a device in the notation of swift-ring-builder
+func (device DeviceInfo) tiers() [4]string {
+	region := fmt.Sprintf("r%d", device.Region)
+	zone := fmt.Sprintf("%sz%d", region, device.Zone)
+	server := fmt.Sprintf("%s-%s", zone, device.NodeIP)
+	return [4]string{region, zone, server, fmt.Sprintf("%s:%d/%s", server, device.Port, device.Name)}
+}
+
+// maxReplicasByTier calculates how many replicas of a partition each tier may hold like swift-ring-builder does:
+// the replicas are spread evenly across the regions, then across the zones of a region and so on, rounding up.
+// Only devices with weight are taken into account.
+func (ring RingInfo) maxReplicasByTier() map[string]float64 {
+	children := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, device := range ring.Devices {
+		if device.Weight == 0 {
+			continue
+		}
+		parent := ""
+		for _, tier := range device.tiers() {
+			if !seen[tier] {
+				seen[tier] = true
+				children[parent] = append(children[parent], tier)
+			}
+			parent = tier
+		}
+	}
+
+	maxReplicas := make(map[string]float64)
+	var walk func(tier string, replicas float64)
+	walk = func(tier string, replicas float64) {
+		maxReplicas[tier] = replicas
+		for _, child := range children[tier] {
+			walk(child, math.Ceil(replicas/float64(len(children[tier]))))
+		}
+	}
+	walk("", ring.Replicas)
+	delete(maxReplicas, "")
+	return maxReplicas
+}
+
+// UndispersedTiers returns the tiers which hold more replicas of a partition than they should together with the
+// number of affected partitions, ordered by the number of partitions. Returns nil if the partition assignment is unknown.
+func (ring RingInfo) UndispersedTiers() []TierDispersion {
+	if len(ring.Replica2Part2Dev) == 0 {
+		return nil
+	}
+
+	devices := make(map[uint64]DeviceInfo, len(ring.Devices))
+	for _, device := range ring.Devices {
+		devices[device.ID] = device
+	}
+	maxReplicas := ring.maxReplicasByTier()
+
+	affected := make(map[string]uint64)
+	for partition := range ring.Replica2Part2Dev[0] {
+		replicasByTier := make(map[string]float64)
+		for _, row := range ring.Replica2Part2Dev {
+			if partition >= len(row) {
+				continue
+			}
+			for _, tier := range devices[row[partition]].tiers() {
+				replicasByTier[tier]++
+			}
+		}
+		for tier, replicas := range replicasByTier {
+			if replicas > maxReplicas[tier] {
+				affected[tier]++
+			}
+		}
+	}
+
+	result := make([]TierDispersion, 0, len(affected))
+	for tier, partitions := range affected {
+		result = append(result, TierDispersion{Tier: tier, Partitions: partitions})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Partitions != result[j].Partitions {
+			return result[i].Partitions > result[j].Partitions
+		}
+		return result[i].Tier < result[j].Tier
+	})
+	return result
+}
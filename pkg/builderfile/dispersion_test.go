@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+)
+
+func TestUndispersedTiers(t *testing.T) {
+	ring := RingInfo{
+		Replicas: 2,
+		Devices:  movementDevices,
+		// partition 0 has both replicas in zone 1 and partition 2 both replicas in zone 2
+		Replica2Part2Dev: [][]uint64{{0, 0, 2, 2}, {1, 2, 3, 0}},
+	}
+
+	assert.DeepEqual(t, "tiers", ring.UndispersedTiers(), []TierDispersion{
+		{Tier: "r1z1", Partitions: 1},
+		{Tier: "r1z1-10.114.1.202", Partitions: 1},
+		{Tier: "r1z2", Partitions: 1},
+		{Tier: "r1z2-10.114.1.203", Partitions: 1},
+	})
+	assert.DeepEqual(t, "unknown assignment", RingInfo{}.UndispersedTiers(), []TierDispersion(nil))
+}
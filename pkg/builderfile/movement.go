@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// newReplica is used as source of partition replicas which did not exist before, e.g. after the replica count was raised
+const newReplica = "(new replica)"
+
+// Moves counts the partition replicas which moved from a source to a target
+type Moves struct {
+	Source   string
+	Target   string
+	Replicas uint64
+}
+
+// PartitionMovement contains how many partition replicas moved between two versions of a ring
+// broken down by source and target device, zone and region.
+type PartitionMovement struct {
+	// TotalReplicas is the number of partition replicas after the change
+	TotalReplicas uint64
+	MovedReplicas uint64
+	Devices       []Moves
+	Zones         []Moves
+	Regions       []Moves
+}
+
+// MovedPercent returns the share of partition replicas which moved
+func (movement PartitionMovement) MovedPercent() float64 {
+	if movement.TotalReplicas == 0 {
+		return 0
+	}
+	return float64(movement.MovedReplicas) / float64(movement.TotalReplicas) * 100
+}
+
+// EstimatedBytes estimates the moved data from the average size of a partition replica,
+// which is calculated from the used capacity of all devices together.
+func (movement PartitionMovement) EstimatedBytes(usedBytes uint64) uint64 {
+	if movement.TotalReplicas == 0 {
+		return 0
+	}
+	return uint64(float64(usedBytes) / float64(movement.TotalReplicas) * float64(movement.MovedReplicas))
+}
+
+// movesCounter aggregates moves by their source and target
+type movesCounter map[[2]string]uint64
+
+func (counter movesCounter) sorted() []Moves {
+	result := make([]Moves, 0, len(counter))
+	for key, replicas := range counter {
+		result = append(result, Moves{Source: key[0], Target: key[1], Replicas: replicas})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Replicas != result[j].Replicas {
+			return result[i].Replicas > result[j].Replicas
+		}
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
+
+// CalculatePartitionMovement compares the partition assignment of two versions of a ring.
+// A partition replica counts as moved if the partition was not stored on its device before.
+// Replicas that are dropped because the replica count was lowered do not count as moved.
+func CalculatePartitionMovement(before, after RingInfo) (PartitionMovement, error) {
+	if len(after.Replica2Part2Dev) == 0 {
+		return PartitionMovement{}, errors.New("the partition assignment is unknown, the ring needs to be read from a builder file which was rebalanced at least once")
+	}
+	partitions := len(after.Replica2Part2Dev[0])
+	if len(before.Replica2Part2Dev) > 0 && len(before.Replica2Part2Dev[0]) != partitions {
+		return PartitionMovement{}, fmt.Errorf("the partition count changed from %d to %d, movement cannot be calculated across a partition power change",
+			len(before.Replica2Part2Dev[0]), partitions)
+	}
+
+	devicesByID := func(ring RingInfo) map[uint64]DeviceInfo {
+		result := make(map[uint64]DeviceInfo, len(ring.Devices))
+		for _, device := range ring.Devices {
+			result[device.ID] = device
+		}
+		return result
+	}
+	beforeDevices, afterDevices := devicesByID(before), devicesByID(after)
+	tiers := func(devices map[uint64]DeviceInfo, id uint64) (device, zone, region string, err error) {
+		dev, ok := devices[id]
+		if !ok {
+			return "", "", "", fmt.Errorf("partitions are assigned to device %d which does not exist", id)
+		}
+		return dev.Label(), fmt.Sprintf("r%dz%d", dev.Region, dev.Zone), fmt.Sprintf("r%d", dev.Region), nil
+	}
+
+	var movement PartitionMovement
+	devices, zones, regions := make(movesCounter), make(movesCounter), make(movesCounter)
+	for partition := range partitions {
+		// fractional replica counts make the last replica row shorter
+		var previous, current []uint64
+		for _, row := range before.Replica2Part2Dev {
+			if partition < len(row) {
+				previous = append(previous, row[partition])
+			}
+		}
+		for _, row := range after.Replica2Part2Dev {
+			if partition < len(row) {
+				current = append(current, row[partition])
+			}
+		}
+		movement.TotalReplicas += uint64(len(current))
+
+		// replicas which stayed on their device did not move, the remaining sources and targets are paired in order
+		var targets []uint64
+		for _, id := range current {
+			if idx := slices.Index(previous, id); idx != -1 {
+				previous = slices.Delete(previous, idx, idx+1)
+			} else {
+				targets = append(targets, id)
+			}
+		}
+
+		for idx, target := range targets {
+			targetDevice, targetZone, targetRegion, err := tiers(afterDevices, target)
+			if err != nil {
+				return PartitionMovement{}, err
+			}
+			sourceDevice, sourceZone, sourceRegion := newReplica, newReplica, newReplica
+			if idx < len(previous) {
+				sourceDevice, sourceZone, sourceRegion, err = tiers(beforeDevices, previous[idx])
+				if err != nil {
+					return PartitionMovement{}, err
+				}
+			}
+
+			movement.MovedReplicas++
+			devices[[2]string{sourceDevice, targetDevice}]++
+			zones[[2]string{sourceZone, targetZone}]++
+			regions[[2]string{sourceRegion, targetRegion}]++
+		}
+	}
+
+	movement.Devices = devices.sorted()
+	movement.Zones = zones.sorted()
+	movement.Regions = regions.sorted()
+	return movement, nil
+}
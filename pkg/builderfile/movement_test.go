@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package builderfile
+
+import (
+	"testing"
+
+	"github.com/nlpodyssey/gopickle/types"
+	"github.com/sapcc/go-bits/assert"
+)
+
+var movementDevices = []DeviceInfo{
+	{ID: 0, Region: 1, Zone: 1, NodeIP: "10.114.1.202", Port: 6001, Name: "swift-01", Weight: 100},
+	{ID: 1, Region: 1, Zone: 1, NodeIP: "10.114.1.202", Port: 6001, Name: "swift-02", Weight: 100},
+	{ID: 2, Region: 1, Zone: 2, NodeIP: "10.114.1.203", Port: 6001, Name: "swift-01", Weight: 100},
+	{ID: 3, Region: 1, Zone: 2, NodeIP: "10.114.1.203", Port: 6001, Name: "swift-02", Weight: 100},
+}
+
+func TestCalculatePartitionMovement(t *testing.T) {
+	before := RingInfo{
+		Devices:          movementDevices[:3],
+		Replica2Part2Dev: [][]uint64{{0, 1, 0, 1}, {2, 2, 2, 2}},
+	}
+	after := RingInfo{
+		Devices:          movementDevices,
+		Replica2Part2Dev: [][]uint64{{0, 1, 3, 1}, {2, 3, 2, 2}},
+	}
+
+	movement, err := CalculatePartitionMovement(before, after)
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "movement", movement, PartitionMovement{
+		TotalReplicas: 8,
+		MovedReplicas: 2,
+		Devices: []Moves{
+			{Source: "z1 10.114.1.202:6001/swift-01", Target: "z2 10.114.1.203:6001/swift-02", Replicas: 1},
+			{Source: "z2 10.114.1.203:6001/swift-01", Target: "z2 10.114.1.203:6001/swift-02", Replicas: 1},
+		},
+		Zones: []Moves{
+			{Source: "r1z1", Target: "r1z2", Replicas: 1},
+			{Source: "r1z2", Target: "r1z2", Replicas: 1},
+		},
+		Regions: []Moves{
+			{Source: "r1", Target: "r1", Replicas: 2},
+		},
+	})
+	assert.Equal(t, movement.MovedPercent(), 25.0)
+	assert.Equal(t, movement.EstimatedBytes(8000), uint64(2000))
+}
+
+func TestCalculatePartitionMovementNewReplicas(t *testing.T) {
+	before := RingInfo{
+		Devices:          movementDevices,
+		Replica2Part2Dev: [][]uint64{{0, 1}, {2, 3}},
+	}
+	// a replica count of 2.5 only adds a third replica to every second partition
+	after := RingInfo{
+		Devices:          movementDevices,
+		Replica2Part2Dev: [][]uint64{{0, 1}, {2, 3}, {1}},
+	}
+
+	movement, err := CalculatePartitionMovement(before, after)
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, movement.TotalReplicas, uint64(5))
+	assert.DeepEqual(t, "zones", movement.Zones, []Moves{{Source: "(new replica)", Target: "r1z1", Replicas: 1}})
+}
+
+func TestCalculatePartitionMovementErrors(t *testing.T) {
+	_, err := CalculatePartitionMovement(RingInfo{}, RingInfo{})
+	assert.ErrEqual(t, err, "the partition assignment is unknown, the ring needs to be read from a builder file which was rebalanced at least once")
+
+	_, err = CalculatePartitionMovement(RingInfo{Replica2Part2Dev: [][]uint64{{0, 0}}}, RingInfo{Replica2Part2Dev: [][]uint64{{0, 0, 0, 0}}})
+	assert.ErrEqual(t, err, "the partition count changed from 2 to 4, movement cannot be calculated across a partition power change")
+
+	_, err = CalculatePartitionMovement(RingInfo{Devices: movementDevices, Replica2Part2Dev: [][]uint64{{0}}}, RingInfo{Replica2Part2Dev: [][]uint64{{7}}})
+	assert.ErrEqual(t, err, "partitions are assigned to device 7 which does not exist")
+}
+
+func TestDecodeReplica2Part2Dev(t *testing.T) {
+	// Python 3 pickles arrays as lists
+	fromList, err := (&Array{}).Call("H", types.NewListFromSlice([]any{0, 1, 2}))
+	assert.ErrEqual(t, err, nil)
+	// Python 2 pickles arrays as strings of little endian machine values
+	fromString, err := (&Array{}).Call("H", "\x03\x00\x04\x01")
+	assert.ErrEqual(t, err, nil)
+
+	replica2Part2Dev, err := decodeReplica2Part2Dev(types.NewListFromSlice([]any{fromList, fromString}))
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "replica2part2dev", replica2Part2Dev, [][]uint64{{0, 1, 2}, {3, 260}})
+
+	replica2Part2Dev, err = decodeReplica2Part2Dev(nil)
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "not rebalanced", replica2Part2Dev, [][]uint64(nil))
+
+	_, err = decodeReplica2Part2Dev(types.NewListFromSlice([]any{"broken"}))
+	assert.ErrEqual(t, err, "can't translate type string in _replica2part2dev")
+
+	_, err = (&Array{}).Call("H", "\x03")
+	assert.ErrEqual(t, err, `cannot decode array of type "H" with 1 bytes`)
+}
@@ -4,15 +4,15 @@
 package builderfile
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/nlpodyssey/gopickle/pickle"
 	"github.com/nlpodyssey/gopickle/types"
-	"github.com/sapcc/go-bits/logg"
-	"github.com/sapcc/go-bits/must"
 )
 
 type pickleData struct {
@@ -23,22 +23,42 @@ type pickleData struct {
 	Partitions uint64       `mapstructure:"parts"`
 	Version    uint64
 	Overload   float64
+
+	PartPower     uint64 `mapstructure:"part_power"`
+	NextPartPower uint64 `mapstructure:"next_part_power"`
+
+	MinPartHours       uint64  `mapstructure:"min_part_hours"`
+	LastPartMovesEpoch float64 `mapstructure:"_last_part_moves_epoch"`
+
+	Replica2Part2Dev [][]uint64 `mapstructure:"_replica2part2dev"`
 }
 
-func unmarshal(input any) pickleData {
+func unmarshal(input any) (pickleData, error) {
+	data, err := guessType(input)
+	if err != nil {
+		return pickleData{}, err
+	}
 	var mappedData pickleData
-	must.Succeed(mapstructure.Decode(guessType(input), &mappedData))
-	return mappedData
+	err = mapstructure.Decode(data, &mappedData)
+	return mappedData, err
 }
 
-func guessType(input any) any {
+func guessType(input any) (any, error) {
 	switch v := input.(type) {
 	case *types.Dict:
 		data := make(map[string]any)
 		for _, entry := range *v {
 			key := entry.Key.(string)
 			// skip keys which have tuple indexed Dicts
-			if key == "_dispersion_graph" || key == "_replica2part2dev" || key == "_last_part_moves" {
+			if key == "_dispersion_graph" || key == "_last_part_moves" {
+				continue
+			}
+			if key == "_replica2part2dev" {
+				replica2Part2Dev, err := decodeReplica2Part2Dev(entry.Value)
+				if err != nil {
+					return nil, err
+				}
+				data[key] = replica2Part2Dev
 				continue
 			}
 			// skip balance to avoid rounding errors when comparing with text based parser
@@ -51,15 +71,19 @@ func guessType(input any) any {
 				if value := entry.Value.(string); value != "" {
 					err := json.Unmarshal([]byte(value), &meta)
 					if err != nil {
-						logg.Fatal("Unmarshalling meta failed: ", err.Error())
+						return nil, fmt.Errorf("unmarshalling meta failed: %w", err)
 					}
 				}
 				data[entry.Key.(string)] = meta
 				continue
 			}
-			data[entry.Key.(string)] = guessType(entry.Value)
+			value, err := guessType(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+			data[entry.Key.(string)] = value
 		}
-		return data
+		return data, nil
 	case *types.List:
 		var data []any
 		for _, entry := range *v {
@@ -67,32 +91,85 @@ func guessType(input any) any {
 			if entry == nil {
 				continue
 			}
-			data = append(data, guessType(entry))
+			value, err := guessType(entry)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, value)
 		}
-		return data
+		return data, nil
 	case bool, float64, int, nil, string:
-		return v
+		return v, nil
 	default:
-		logg.Fatal("Can't translate type %T", v)
+		return nil, fmt.Errorf("can't translate type %T", v)
 	}
-
-	return nil
 }
 
 type Array types.List
 
 var _ types.Callable = &Array{}
 
+// arrayItemSizes contains the item size of the unsigned array type codes used by swift
+var arrayItemSizes = map[string]int{"B": 1, "H": 2, "I": 4, "L": 8}
+
+// Call reconstructs an array.array. Python 3 pickles the items as a list while Python 2 pickles them as a string of machine values.
 func (*Array) Call(args ...any) (any, error) {
-	// TODO: make fully functional
-	// args[0] contains a type like B or H which is not taken into account
-	return args[1].(*types.List), nil
+	if len(args) != 2 {
+		return nil, fmt.Errorf("array expects 2 arguments but got %d", len(args))
+	}
+	switch items := args[1].(type) {
+	case *types.List:
+		return items, nil
+	case string:
+		typeCode, _ := args[0].(string)
+		itemSize, ok := arrayItemSizes[typeCode]
+		if !ok || len(items)%itemSize != 0 {
+			return nil, fmt.Errorf("cannot decode array of type %q with %d bytes", typeCode, len(items))
+		}
+		list := types.NewList()
+		for i := 0; i < len(items); i += itemSize {
+			// the items are stored in the byte order of the machine which wrote the builder file, which is little endian in practice
+			var buf [8]byte
+			copy(buf[:], items[i:i+itemSize])
+			list.Append(int(binary.LittleEndian.Uint64(buf[:]))) //nolint:gosec // array items are never larger than int
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("cannot decode array from %T", args[1])
+	}
+}
+
+// decodeReplica2Part2Dev converts the arrays which assign every replica of every partition to a device ID.
+// Builder files which were never rebalanced do not contain an assignment yet.
+func decodeReplica2Part2Dev(input any) ([][]uint64, error) {
+	replicas, ok := input.(*types.List)
+	if !ok {
+		return nil, nil
+	}
+
+	result := make([][]uint64, 0, replicas.Len())
+	for _, replica := range *replicas {
+		partitions, ok := replica.(*types.List)
+		if !ok {
+			return nil, fmt.Errorf("can't translate type %T in _replica2part2dev", replica)
+		}
+		deviceIDs := make([]uint64, partitions.Len())
+		for partition, deviceID := range *partitions {
+			id, ok := deviceID.(int)
+			if !ok || id < 0 {
+				return nil, fmt.Errorf("can't translate device ID %v in _replica2part2dev", deviceID)
+			}
+			deviceIDs[partition] = uint64(id)
+		}
+		result = append(result, deviceIDs)
+	}
+	return result, nil
 }
 
-func decodeBuilderFile(builderFilename string) pickleData {
+func decodeBuilderFile(builderFilename string) (pickleData, error) {
 	builderReader, err := os.Open(builderFilename)
 	if err != nil {
-		logg.Fatal("Reading file failed: %s", err.Error())
+		return pickleData{}, fmt.Errorf("reading file failed: %w", err)
 	}
 	defer builderReader.Close()
 	u := pickle.NewUnpickler(builderReader)
@@ -102,7 +179,10 @@ func decodeBuilderFile(builderFilename string) pickleData {
 		}
 		return nil, errors.New("class not found :(")
 	}
-	pickled := must.Return(u.Load())
+	pickled, err := u.Load()
+	if err != nil {
+		return pickleData{}, fmt.Errorf("unpickling %s failed: %w", builderFilename, err)
+	}
 
 	return unmarshal(pickled)
 }
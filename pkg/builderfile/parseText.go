@@ -21,7 +21,8 @@ import (
 // regex to match the following line:
 // container.builder, build version 7, id 024e79c994c643d09eb045d488dafb94
 // account.builder, build version 37, id (not assigned)
-var fileInfoRx = regroup.MustCompile(`^(?:[\w\/\.-]+\/)?(?P<fileName>\w+\.builder), build version (?P<buildVersion>\d+), id (?:(?P<id>[\d\w]{32})|\(not assigned\))$`)
+// object-1.builder, build version 12, id 024e79c994c643d09eb045d488dafb94
+var fileInfoRx = regroup.MustCompile(`^(?:[\w\/\.-]+\/)?(?P<fileName>[\w-]+\.builder), build version (?P<buildVersion>\d+), id (?:(?P<id>[\d\w]{32})|\(not assigned\))$`)
 
 // regex to match the following line:
 // 1024 partitions, 3.000000 replicas, 1 regions, 1 zones, 6 devices, 0.00 balance, 0.00 dispersion
@@ -35,10 +36,15 @@ var remainingTimeRx = regroup.MustCompile(`^The minimum number of hours before a
 // The overload factor is 0.00% (0.000000)
 var overloadFactorRx = regroup.MustCompile(`^The overload factor is (?P<percent>\d+\.\d+)% \((?P<decimal>\d+\.\d+)\)$`)
 
+// regex to match the following line:
+// Preparing increase of partition power (11)
+var nextPartPowerRx = regroup.MustCompile(`^Preparing increase of partition power \((?P<nextPartPower>\d+)\)$`)
+
 // regex to match the following line:
 // Ring file container.ring.gz is obsolete
 // Ring file container.ring.gz is up-to-date
-var obsoleteRx = regexp.MustCompile(`^Ring file (?:[\w\/\.-]+\/)?\w+\.ring\.gz is (obsolete|up-to-date)$`)
+// Ring file object-1.ring.gz is up-to-date
+var obsoleteRx = regexp.MustCompile(`^Ring file (?:[\w\/\.-]+\/)?[\w-]+\.ring\.gz is (obsolete|up-to-date)$`)
 
 // regex to match the following line:
 // Devices:   id region zone   ip address:port replication ip:port  name weight partitions balance flags meta
@@ -78,6 +84,15 @@ func (ring RingInfo) FindDevice(zone uint64, nodeIP string, port uint64, diskNam
 
 // Input parses an input and return the data as MetData object
 func Input(input io.Reader) RingInfo {
+	metaData, err := Parse(input)
+	if err != nil {
+		logg.Fatal(err.Error())
+	}
+	return metaData
+}
+
+// Parse parses the output of swift-ring-builder like Input but returns an error instead of stopping the process
+func Parse(input io.Reader) (RingInfo, error) {
 	var metaData RingInfo
 	scanner := bufio.NewScanner(input)
 	for scanner.Scan() {
@@ -122,6 +137,13 @@ func Input(input io.Reader) RingInfo {
 			continue
 		}
 
+		matches, _ = nextPartPowerRx.Groups(line) //nolint:errcheck
+		if len(matches) > 0 {
+			// errors can be ignored because the regex matches digits (\d)
+			metaData.NextPartPower = misc.ParseUint(matches["nextPartPower"])
+			continue
+		}
+
 		// this line is purely informational but we need to match it anyway to not abort the process
 		if obsoleteRx.MatchString(line) {
 			continue
@@ -131,7 +153,7 @@ func Input(input io.Reader) RingInfo {
 			break
 		}
 
-		logg.Fatal("A header regex did not match the line: \"%s\"", line)
+		return RingInfo{}, fmt.Errorf("a header regex did not match the line: %q", line)
 	}
 
 	for scanner.Scan() {
@@ -146,7 +168,7 @@ func Input(input io.Reader) RingInfo {
 				// logg.Info("%#v", matches["meta"])
 				err := json.Unmarshal([]byte(matches["meta"]), &meta)
 				if err != nil {
-					logg.Fatal("Unmsharshaling meta from swift-ring-builder output failed: %s %s", matches["meta"], err.Error())
+					return RingInfo{}, fmt.Errorf("unmarshalling meta %s from swift-ring-builder output failed: %w", matches["meta"], err)
 				}
 			}
 
@@ -169,12 +191,12 @@ func Input(input io.Reader) RingInfo {
 			continue
 		}
 
-		logg.Fatal("The table entry regex did not match the line: %s", line)
+		return RingInfo{}, fmt.Errorf("the table entry regex did not match the line: %s", line)
 	}
 
 	if err := scanner.Err(); err != nil {
-		logg.Fatal("Reading input failed: %s", err.Error())
+		return RingInfo{}, fmt.Errorf("reading input failed: %w", err)
 	}
 
-	return metaData
+	return metaData, nil
 }
@@ -6,6 +6,7 @@ package builderfile
 import (
 	"encoding/json"
 	"fmt"
+	"math/bits"
 	"time"
 )
 
@@ -40,6 +41,11 @@ type RingInfo struct {
 	Balance     float64
 	Dispersion  float64
 
+	// PartPower is only known when reading builder files, use CurrentPartPower instead
+	PartPower uint64 `yaml:"part_power,omitempty"`
+	// NextPartPower is set while the partition power is being increased
+	NextPartPower uint64 `yaml:"next_part_power,omitempty"`
+
 	ReassignedCooldown  uint64    `yaml:"reassigned_cooldown"`
 	ReassignedRemaining time.Time `yaml:"reassigned_remaining"`
 
@@ -47,6 +53,29 @@ type RingInfo struct {
 	OverloadFactorDecimal float64 `yaml:"overload_factor_decimal"`
 
 	Devices []DeviceInfo
+
+	// Replica2Part2Dev contains the device ID of every replica of every partition.
+	// It is only known when reading builder files which have been rebalanced at least once.
+	Replica2Part2Dev [][]uint64 `yaml:"replica2part2dev,omitempty"`
+}
+
+// CurrentPartPower returns the partition power of the ring, derived from the partition count if necessary
+func (ring RingInfo) CurrentPartPower() uint64 {
+	if ring.PartPower != 0 || ring.Partitions == 0 {
+		return ring.PartPower
+	}
+	return uint64(bits.Len64(ring.Partitions) - 1)
+}
+
+// reassignedRemainingBase is the point in time that time.Parse returns for a duration like "0:00:00" parsed with time.TimeOnly
+var reassignedRemainingBase = time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// MinPartSecondsLeft returns the time until partitions can be reassigned again
+func (ring RingInfo) MinPartSecondsLeft() time.Duration {
+	if ring.ReassignedRemaining.IsZero() {
+		return 0
+	}
+	return ring.ReassignedRemaining.Sub(reassignedRemainingBase)
 }
 
 func (device DeviceInfo) IPAddressPort() string {
@@ -57,6 +86,35 @@ func (ring RingInfo) CommandSetOverload(ringFilename string, desiredOverload flo
 	return fmt.Sprintf("swift-ring-builder %s set_overload %f", ringFilename, desiredOverload)
 }
 
+// CommandCreate returns the command to create a new and empty builder file
+func CommandCreate(ringFilename string, partPower uint64, replicas float64, minPartHours uint64) string {
+	return fmt.Sprintf("swift-ring-builder %s create %d %g %d", ringFilename, partPower, replicas, minPartHours)
+}
+
+func (ring RingInfo) CommandPrepareIncreasePartitionPower(ringFilename string) string {
+	return fmt.Sprintf("swift-ring-builder %s prepare_increase_partition_power", ringFilename)
+}
+
+func (ring RingInfo) CommandIncreasePartitionPower(ringFilename string) string {
+	return fmt.Sprintf("swift-ring-builder %s increase_partition_power", ringFilename)
+}
+
+func (ring RingInfo) CommandFinishIncreasePartitionPower(ringFilename string) string {
+	return fmt.Sprintf("swift-ring-builder %s finish_increase_partition_power", ringFilename)
+}
+
+func (ring RingInfo) CommandSetMinPartHours(ringFilename string, desiredMinPartHours uint64) string {
+	return fmt.Sprintf("swift-ring-builder %s set_min_part_hours %d", ringFilename, desiredMinPartHours)
+}
+
+func (ring RingInfo) CommandPretendMinPartHoursPassed(ringFilename string) string {
+	return fmt.Sprintf("swift-ring-builder %s pretend_min_part_hours_passed", ringFilename)
+}
+
+func (ring RingInfo) CommandSetReplicas(ringFilename string, desiredReplicas float64) string {
+	return fmt.Sprintf("swift-ring-builder %s set_replicas %g", ringFilename, desiredReplicas)
+}
+
 func (device DeviceInfo) CommandAdd(ringFilename string) string {
 	if device.Meta != nil {
 		var meta []byte
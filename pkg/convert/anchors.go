@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// MarshalYAML encodes a rule file and groups identical nodes with YAML anchors and aliases.
+// The first occurrence of a node gets an anchor like &node-1 and all further identical nodes refer to it with *node-1.
+func MarshalYAML(file map[string]rules.RingRules) ([]byte, error) {
+	var document yaml.Node
+	if err := document.Encode(file); err != nil {
+		return nil, err
+	}
+
+	anchors := nodeAnchors{seen: make(map[string]*yaml.Node)}
+	for _, ringNode := range mappingValues(&document) {
+		for _, zonesNode := range mappingValue(ringNode, "zones") {
+			for _, zoneNode := range mappingValues(zonesNode) {
+				for _, nodesNode := range mappingValue(zoneNode, "nodes") {
+					if err := anchors.apply(nodesNode); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nodeAnchors remembers the nodes which were already seen by their encoded content
+type nodeAnchors struct {
+	seen  map[string]*yaml.Node
+	count int
+}
+
+// apply replaces nodes which are identical to an already seen node with an alias
+func (anchors *nodeAnchors) apply(nodesNode *yaml.Node) error {
+	for i := 1; i < len(nodesNode.Content); i += 2 {
+		nodeRules := nodesNode.Content[i]
+		key, err := yaml.Marshal(nodeRules)
+		if err != nil {
+			return err
+		}
+
+		anchor, ok := anchors.seen[string(key)]
+		if !ok {
+			anchors.seen[string(key)] = nodeRules
+			continue
+		}
+		if anchor.Anchor == "" {
+			anchors.count++
+			anchor.Anchor = fmt.Sprintf("node-%d", anchors.count)
+		}
+		nodesNode.Content[i] = &yaml.Node{Kind: yaml.AliasNode, Value: anchor.Anchor, Alias: anchor}
+	}
+	return nil
+}
+
+// mappingValues returns the values of a mapping node, unwrapping a document node if necessary
+func mappingValues(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var values []*yaml.Node
+	for i := 1; i < len(node.Content); i += 2 {
+		values = append(values, node.Content[i])
+	}
+	return values
+}
+
+// mappingValue returns the value of key in a mapping node as a slice with zero or one element
+func mappingValue(node *yaml.Node, key string) []*yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return []*yaml.Node{node.Content[i+1]}
+		}
+	}
+	return nil
+}
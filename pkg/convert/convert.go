@@ -4,42 +4,125 @@
 package convert
 
 import (
+	"math"
+	"reflect"
+	"slices"
+
 	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
 	"github.com/sapcc/swift-ring-artisan/pkg/rules"
 )
 
+type nodeKey struct {
+	Zone   uint64
+	NodeIP string
+}
+
 // Convert converts parsed MetaData to DiskRules
-func Convert(ring builderfile.RingInfo, baseSize float64) rules.RingRules {
+func Convert(ring builderfile.RingInfo, baseSize misc.ByteSize) rules.RingRules {
 	diskRules := rules.RingRules{
-		Region:     1, // FIXME: make multi region aware
-		BasePort:   ring.Devices[0].Port,
-		BaseSizeTB: baseSize,
-		Zones:      make(map[uint64]*rules.ZoneRules),
+		Region:   1, // FIXME: make multi region aware
+		BaseSize: baseSize,
+		Overload: ring.OverloadFactorDecimal,
+		Zones:    make(map[uint64]*rules.ZoneRules),
 	}
 
-	var diskRulesZone *rules.ZoneRules
+	// group the devices by node while keeping the order of the builder file
+	var nodeKeys []nodeKey
+	nodeDevices := make(map[nodeKey][]builderfile.DeviceInfo)
 	for _, device := range ring.Devices {
-		// create zone if it does not exist
-		if _, ok := diskRules.Zones[device.Zone]; len(diskRules.Zones) == 0 || !ok {
-			diskRules.Zones[device.Zone] = &rules.ZoneRules{}
+		key := nodeKey{Zone: device.Zone, NodeIP: device.NodeIP}
+		if _, ok := nodeDevices[key]; !ok {
+			nodeKeys = append(nodeKeys, key)
 		}
+		nodeDevices[key] = append(nodeDevices[key], device)
+	}
+
+	diskRules.BasePort = mostCommonPort(nodeKeys, nodeDevices)
 
-		diskRulesZone = diskRules.Zones[device.Zone]
-		// if the last IPAddressPort matches the current, there is another disk on the same note, just increase the count
-		if _, ok := diskRulesZone.Nodes[device.NodeIP]; ok {
-			diskRulesZone.Nodes[device.NodeIP].DiskCount++
-			continue
+	for _, key := range nodeKeys {
+		devices := nodeDevices[key]
+
+		// create zone if it does not exist
+		if _, ok := diskRules.Zones[key.Zone]; !ok {
+			diskRules.Zones[key.Zone] = &rules.ZoneRules{Nodes: make(map[string]*rules.NodeRules)}
 		}
 
-		if diskRulesZone.Nodes == nil {
-			diskRulesZone.Nodes = make(map[string]*rules.NodeRules)
+		nodeRules := &rules.NodeRules{}
+		nodeRules.DiskCount, nodeRules.BrokenDisks = countDisks(devices)
+
+		if port := devices[0].Port; port != diskRules.BasePort {
+			nodeRules.Port = port
+		}
+		if meta := devices[0].Meta; meta != nil && len(*meta) > 0 && allDevices(devices, func(d builderfile.DeviceInfo) bool { return reflect.DeepEqual(d.Meta, meta) }) {
+			metaCopy := *meta
+			nodeRules.Meta = &metaCopy
 		}
-		weight := device.Weight
-		diskRulesZone.Nodes[device.NodeIP] = &rules.NodeRules{
-			DiskCount: 1,
-			Weight:    &weight,
+
+		weight := devices[0].Weight
+		if diskSize, ok := inferDiskSize(weight, baseSize); ok && allDevices(devices, func(d builderfile.DeviceInfo) bool { return d.Weight == weight }) {
+			nodeRules.DiskSize = diskSize
+		} else {
+			nodeRules.Weight = &weight
 		}
+
+		diskRules.Zones[key.Zone].Nodes[key.NodeIP] = nodeRules
 	}
 
 	return diskRules
 }
+
+func allDevices(devices []builderfile.DeviceInfo, predicate func(builderfile.DeviceInfo) bool) bool {
+	return !slices.ContainsFunc(devices, func(d builderfile.DeviceInfo) bool { return !predicate(d) })
+}
+
+// mostCommonPort returns the port that most nodes use, which becomes the base_port of the rules
+func mostCommonPort(nodeKeys []nodeKey, nodeDevices map[nodeKey][]builderfile.DeviceInfo) uint64 {
+	var basePort uint64
+	count := make(map[uint64]int)
+	for _, key := range nodeKeys {
+		port := nodeDevices[key][0].Port
+		count[port]++
+		if count[port] > count[basePort] || (count[port] == count[basePort] && port < basePort) {
+			basePort = port
+		}
+	}
+	return basePort
+}
+
+// countDisks derives disk_count from the highest disk number of a node.
+// Numbers without a device in between are reported as broken disks.
+// If the devices do not follow the swift-NN naming scheme, the devices are just counted.
+func countDisks(devices []builderfile.DeviceInfo) (diskCount uint64, brokenDisks []string) {
+	present := make(map[uint64]bool)
+	for _, device := range devices {
+		diskNumber, ok := rules.ParseDiskNumber(device.Name)
+		if !ok || device.Name != rules.DiskName(diskNumber) {
+			return uint64(len(devices)), nil
+		}
+		present[diskNumber] = true
+		diskCount = max(diskCount, diskNumber)
+	}
+
+	for diskNumber := uint64(1); diskNumber <= diskCount; diskNumber++ {
+		if !present[diskNumber] {
+			brokenDisks = append(brokenDisks, rules.DiskName(diskNumber))
+		}
+	}
+	return diskCount, brokenDisks
+}
+
+// inferDiskSize calculates the disk size from a weight that is relative to the base size.
+// It only succeeds if the relative weight policy yields exactly the same weight again,
+// otherwise the weight needs to be kept explicitly.
+func inferDiskSize(weight float64, baseSize misc.ByteSize) (misc.ByteSize, bool) {
+	if weight <= 0 || baseSize == 0 || weight != math.Trunc(weight) {
+		return 0, false
+	}
+	diskSize := misc.ByteSize(math.Round(weight * float64(baseSize) / 100))
+	desired, _, err := rules.RingRules{BaseSize: baseSize}.DesiredWeight(rules.NodeRules{DiskSize: diskSize}, "")
+	if err != nil || desired != weight {
+		return 0, false
+	}
+	return diskSize, true
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/sapcc/go-bits/assert"
+	"gopkg.in/yaml.v2"
 
 	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
 	"github.com/sapcc/swift-ring-artisan/pkg/misc"
@@ -18,9 +19,9 @@ func TestParse1(t *testing.T) {
 	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
 
 	var expected rules.RingRules
-	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &expected)
+	misc.ReadYAML("../../testing/convert-expected-1.yaml", &expected)
 
-	metaData := Convert(input, 6)
+	metaData := Convert(input, 6*misc.Terabyte)
 	assert.DeepEqual(t, "parsing", metaData, expected)
 }
 
@@ -29,8 +30,101 @@ func TestParse2(t *testing.T) {
 	misc.ReadYAML("../../testing/builder-output-2.yaml", &input)
 
 	var expected rules.RingRules
-	misc.ReadYAML("../../testing/artisan-rules-2.yaml", &expected)
+	misc.ReadYAML("../../testing/convert-expected-2.yaml", &expected)
 
-	metaData := Convert(input, 6)
+	metaData := Convert(input, 6*misc.Terabyte)
 	assert.DeepEqual(t, "parsing", metaData, expected)
 }
+
+func TestParseEmptyRing(t *testing.T) {
+	metaData := Convert(builderfile.RingInfo{}, 6*misc.Terabyte)
+	assert.DeepEqual(t, "parsing", metaData, rules.RingRules{
+		Region:   1,
+		BaseSize: 6 * misc.Terabyte,
+		Zones:    make(map[uint64]*rules.ZoneRules),
+	})
+}
+
+func TestParseBrokenDisksAndPorts(t *testing.T) {
+	input := builderfile.RingInfo{Devices: []builderfile.DeviceInfo{
+		{Zone: 1, NodeIP: "10.114.1.202", Port: 6001, Name: "swift-01", Weight: 100},
+		{Zone: 1, NodeIP: "10.114.1.202", Port: 6001, Name: "swift-03", Weight: 100},
+		{Zone: 1, NodeIP: "10.114.1.203", Port: 6001, Name: "swift-01", Weight: 150},
+		{Zone: 1, NodeIP: "10.114.1.203", Port: 6001, Name: "swift-02", Weight: 90},
+		{Zone: 2, NodeIP: "10.114.1.204", Port: 6002, Name: "swift-01", Weight: 33.3},
+		{Zone: 2, NodeIP: "10.114.1.205", Port: 6001, Name: "sdb", Weight: 0},
+		{Zone: 2, NodeIP: "10.114.1.205", Port: 6001, Name: "sdc", Weight: 0},
+	}}
+
+	weight := func(w float64) *float64 { return &w }
+	assert.DeepEqual(t, "parsing", Convert(input, 6*misc.Terabyte), rules.RingRules{
+		Region:   1,
+		BasePort: 6001,
+		BaseSize: 6 * misc.Terabyte,
+		Zones: map[uint64]*rules.ZoneRules{
+			1: {Nodes: map[string]*rules.NodeRules{
+				"10.114.1.202": {DiskCount: 3, DiskSize: 6 * misc.Terabyte, BrokenDisks: []string{"swift-02"}},
+				"10.114.1.203": {DiskCount: 2, Weight: weight(150)},
+			}},
+			2: {Nodes: map[string]*rules.NodeRules{
+				"10.114.1.204": {Port: 6002, DiskCount: 1, Weight: weight(33.3)},
+				"10.114.1.205": {DiskCount: 2, Weight: weight(0)},
+			}},
+		},
+	})
+}
+
+func TestMarshalYAMLAnchors(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	data, err := MarshalYAML(map[string]rules.RingRules{"container.builder": Convert(input, 6*misc.Terabyte)})
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, string(data), `container.builder:
+  base_size: 6TB
+  base_port: 6001
+  region: 1
+  overload: 0
+  zones:
+    1:
+      nodes:
+        10.114.1.202: &node-1
+          disk_count: 3
+          disk_size: 6TB
+        10.114.1.203: *node-1
+`)
+
+	// the aliases need to be readable again
+	var file map[string]rules.RingRules
+	assert.ErrEqual(t, yaml.UnmarshalStrict(data, &file), nil)
+	assert.DeepEqual(t, "parsing", file["container.builder"], Convert(input, 6*misc.Terabyte))
+}
+
+func TestVerify(t *testing.T) {
+	for _, filename := range []string{"builder-output-1.yaml", "builder-output-2.yaml", "builder-output-3.yaml"} {
+		var input builderfile.RingInfo
+		misc.ReadYAML("../../testing/"+filename, &input)
+
+		assert.ErrEqual(t, Verify(input, Convert(input, 6*misc.Terabyte), "/etc/swift/"+input.FileName), nil)
+	}
+
+	// gaps in the disk numbering, differing ports and overload
+	input := builderfile.RingInfo{Regions: 1, OverloadFactorDecimal: 0.1, Devices: []builderfile.DeviceInfo{
+		{Region: 1, Zone: 1, NodeIP: "10.114.1.202", Port: 6001, ReplicationIP: "10.114.1.202", ReplicationPort: 6001, Name: "swift-01", Weight: 100},
+		{Region: 1, Zone: 1, NodeIP: "10.114.1.202", Port: 6001, ReplicationIP: "10.114.1.202", ReplicationPort: 6001, Name: "swift-03", Weight: 100},
+		{Region: 1, Zone: 2, NodeIP: "10.114.1.204", Port: 6002, ReplicationIP: "10.114.1.204", ReplicationPort: 6002, Name: "swift-01", Weight: 33.3},
+	}}
+	assert.ErrEqual(t, Verify(input, Convert(input, 6*misc.Terabyte), "/etc/swift/object.builder"), nil)
+}
+
+func TestVerifyDrift(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+	input.Devices[1].Weight = 50
+
+	// the FileName of rings read from builder files is empty
+	input.FileName = ""
+	err := Verify(input, Convert(input, 6*misc.Terabyte), "/etc/swift/container.builder")
+	assert.ErrEqual(t, err, `converted rules do not match the ring, apply would run:
+  swift-ring-builder /etc/swift/container.builder set_weight --region 1 --zone 1 --ip 10.114.1.202 --port 6001 --device swift-02 --weight 50 100`)
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// Verify checks that applying the converted rules to the ring they were converted from does not change anything.
+// The rules are encoded and parsed again like apply would read them, so that the YAML representation is verified as well.
+// The builder filename is passed explicitly because the FileName of rings read from builder files is empty.
+func Verify(ring builderfile.RingInfo, ringRules rules.RingRules, builderFilename string) error {
+	ringName := filepath.Base(builderFilename)
+	data, err := MarshalYAML(map[string]rules.RingRules{ringName: ringRules})
+	if err != nil {
+		return err
+	}
+	var file map[string]rules.RingRules
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return fmt.Errorf("converted rules cannot be parsed: %w", err)
+	}
+
+	commandQueue, _, err := file[ringName].CalculateChanges(ring, builderFilename)
+	if err != nil {
+		return fmt.Errorf("converted rules cannot be applied: %w", err)
+	}
+	if len(commandQueue) > 0 {
+		return fmt.Errorf("converted rules do not match the ring, apply would run:\n  %s", strings.Join(commandQueue, "\n  "))
+	}
+	return nil
+}
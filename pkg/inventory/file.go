@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+// CSVFile is an inventory in CSV format.
+// The first line is a header with the columns name, rack, ip, port, disk_count and disk_size_tb in any order.
+// The columns name and port are optional.
+type CSVFile struct {
+	Filename string
+}
+
+var _ Source = CSVFile{}
+
+// Servers implements the Source interface
+func (file CSVFile) Servers() ([]Server, error) {
+	reader, err := os.Open(file.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header of %s failed: %w", file.Filename, err)
+	}
+	columns := make(map[string]int, len(header))
+	for idx, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = idx
+	}
+	for _, column := range []string{"rack", "ip", "disk_count"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("%s is missing the column %s", file.Filename, column)
+		}
+	}
+
+	var servers []Server
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s failed: %w", file.Filename, err)
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		value := func(column string) string {
+			idx, ok := columns[column]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		server := Server{
+			Name: value("name"),
+			Rack: value("rack"),
+			IP:   value("ip"),
+		}
+		if server.Name == "" {
+			server.Name = server.IP
+		}
+		if server.Port, err = parseUintColumn(value("port")); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid port: %w", file.Filename, line, err)
+		}
+		if server.DiskCount, err = parseUintColumn(value("disk_count")); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid disk_count: %w", file.Filename, line, err)
+		}
+		if diskSize := value("disk_size_tb"); diskSize != "" {
+			if server.DiskSize, err = misc.ParseByteSize(diskSize); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid disk_size_tb: %w", file.Filename, line, err)
+			}
+		}
+		servers = append(servers, server)
+	}
+
+	return servers, nil
+}
+
+func parseUintColumn(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// JSONFile is an inventory in JSON format containing a list of servers like
+// [{"name": "node01", "rack": "rack-a", "ip": "10.0.0.1", "disk_count": 12, "disk_size_tb": 6}]
+type JSONFile struct {
+	Filename string
+}
+
+var _ Source = JSONFile{}
+
+type jsonServer struct {
+	Name      string        `json:"name"`
+	Rack      string        `json:"rack"`
+	IP        string        `json:"ip"`
+	Port      uint64        `json:"port"`
+	DiskCount uint64        `json:"disk_count"`
+	DiskSize  misc.ByteSize `json:"disk_size_tb"`
+}
+
+// Servers implements the Source interface
+func (file JSONFile) Servers() ([]Server, error) {
+	var jsonServers []jsonServer
+	if err := readJSON(file.Filename, &jsonServers); err != nil {
+		return nil, err
+	}
+
+	servers := make([]Server, 0, len(jsonServers))
+	for _, s := range jsonServers {
+		name := s.Name
+		if name == "" {
+			name = s.IP
+		}
+		servers = append(servers, Server{
+			Name:      name,
+			Rack:      s.Rack,
+			IP:        s.IP,
+			Port:      s.Port,
+			DiskCount: s.DiskCount,
+			DiskSize:  s.DiskSize,
+		})
+	}
+	return servers, nil
+}
+
+func readJSON(filename string, data any) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(data); err != nil {
+		return fmt.Errorf("parsing %s failed: %w", filename, err)
+	}
+	return nil
+}
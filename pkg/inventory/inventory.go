@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// Server is a storage node as described by an inventory
+type Server struct {
+	Name      string
+	Rack      string
+	IP        string
+	Port      uint64
+	DiskCount uint64
+	DiskSize  misc.ByteSize
+}
+
+// Source is an external inventory which knows about the storage nodes of a cluster
+type Source interface {
+	Servers() ([]Server, error)
+}
+
+// Options controls how servers are mapped to rules
+type Options struct {
+	Region   uint64
+	BasePort uint64
+	BaseSize misc.ByteSize
+	// ZoneMap assigns racks to zones. If it is empty, the racks are sorted by name and numbered starting with zone 1.
+	ZoneMap map[string]uint64
+	// HostnameMeta adds the server name as hostname to the meta data of every node.
+	HostnameMeta bool
+}
+
+// BuildRules generates the rules for a ring from the servers of an inventory.
+// Racks are mapped to zones, servers to nodes and their disks to disk_count and disk_size.
+func BuildRules(servers []Server, options Options) (rules.RingRules, error) {
+	ringRules := rules.RingRules{
+		Region:   options.Region,
+		BasePort: options.BasePort,
+		BaseSize: options.BaseSize,
+		Zones:    make(map[uint64]*rules.ZoneRules),
+	}
+
+	zoneMap := options.ZoneMap
+	if len(zoneMap) == 0 {
+		zoneMap = numberRacks(servers)
+	}
+
+	var errs []error
+	seenIPs := make(map[string]string)
+	for _, server := range servers {
+		if server.IP == "" {
+			errs = append(errs, fmt.Errorf("server %s has no IP", server.Name))
+			continue
+		}
+		if other, ok := seenIPs[server.IP]; ok {
+			errs = append(errs, fmt.Errorf("servers %s and %s have the same IP %s", other, server.Name, server.IP))
+			continue
+		}
+		seenIPs[server.IP] = server.Name
+
+		zone, ok := zoneMap[server.Rack]
+		if !ok {
+			errs = append(errs, fmt.Errorf("rack %q of server %s is not mapped to a zone", server.Rack, server.Name))
+			continue
+		}
+		if server.DiskCount == 0 {
+			errs = append(errs, fmt.Errorf("server %s has no disks", server.Name))
+			continue
+		}
+
+		nodeRules := &rules.NodeRules{
+			DiskCount: server.DiskCount,
+			DiskSize:  server.DiskSize,
+		}
+		if server.Port != 0 && server.Port != options.BasePort {
+			nodeRules.Port = server.Port
+		}
+		if options.HostnameMeta && server.Name != "" {
+			nodeRules.Meta = &map[string]string{"hostname": server.Name}
+		}
+
+		if _, ok := ringRules.Zones[zone]; !ok {
+			ringRules.Zones[zone] = &rules.ZoneRules{Nodes: make(map[string]*rules.NodeRules)}
+		}
+		ringRules.Zones[zone].Nodes[server.IP] = nodeRules
+	}
+
+	return ringRules, errors.Join(errs...)
+}
+
+func numberRacks(servers []Server) map[string]uint64 {
+	var racks []string
+	for _, server := range servers {
+		if !slices.Contains(racks, server.Rack) {
+			racks = append(racks, server.Rack)
+		}
+	}
+	slices.Sort(racks)
+
+	zoneMap := make(map[string]uint64, len(racks))
+	for idx, rack := range racks {
+		zoneMap[rack] = uint64(idx) + 1
+	}
+	return zoneMap
+}
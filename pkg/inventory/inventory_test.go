@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+var testOptions = Options{Region: 1, BasePort: 6001, BaseSize: 6 * misc.Terabyte}
+
+func testSource(t *testing.T, source Source) {
+	t.Helper()
+
+	servers, err := source.Servers()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ringRules, err := BuildRules(servers, testOptions)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var expected rules.RingRules
+	misc.ReadYAML("../../testing/artisan-inventory-1.yaml", &expected)
+	assert.DeepEqual(t, "rules", ringRules, expected)
+}
+
+func TestCSVFile(t *testing.T) {
+	testSource(t, CSVFile{Filename: "../../testing/inventory-1.csv"})
+}
+
+func TestJSONFile(t *testing.T) {
+	testSource(t, JSONFile{Filename: "../../testing/inventory-1.json"})
+}
+
+func TestNetBoxExport(t *testing.T) {
+	testSource(t, NetBoxExport{Filename: "../../testing/inventory-netbox.json"})
+}
+
+func TestNetBoxExportPaginated(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "devices.json")
+	must.SucceedT(t, os.WriteFile(filename, []byte(`{"count": 4, "next": "https://netbox/api/dcim/devices/?limit=2&offset=2", "previous": null, "results": []}`), 0644))
+
+	_, err := NetBoxExport{Filename: filename}.Servers()
+	assert.ErrEqual(t, err, filename+" contains only one page of the devices, export all devices at once, e.g. with ?limit=0")
+}
+
+func TestBuildRulesErrors(t *testing.T) {
+	servers := []Server{
+		{Name: "node01", Rack: "rack-a", IP: "10.0.0.1", DiskCount: 12},
+		{Name: "node02", Rack: "rack-a", IP: "10.0.0.1", DiskCount: 12},
+		{Name: "node03", Rack: "rack-c", IP: "10.0.0.3", DiskCount: 12},
+		{Name: "node04", Rack: "rack-a", IP: "10.0.0.4"},
+	}
+	options := testOptions
+	options.ZoneMap = map[string]uint64{"rack-a": 1}
+
+	_, err := BuildRules(servers, options)
+	assert.ErrEqual(t, err, `servers node01 and node02 have the same IP 10.0.0.1
+rack "rack-c" of server node03 is not mapped to a zone
+server node04 has no disks`)
+}
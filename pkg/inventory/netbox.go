@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+// NetBoxExport is a JSON export of the NetBox devices API (/api/dcim/devices/).
+// The disks of a device are read from the custom fields disk_count and disk_size_tb.
+// Devices which are not active are skipped. The export needs to contain all devices, e.g. fetched with ?limit=0,
+// a page of a paginated response is rejected to not drop the servers of the other pages from the rules.
+type NetBoxExport struct {
+	Filename string
+}
+
+var _ Source = NetBoxExport{}
+
+type netboxDevice struct {
+	Name   string `json:"name"`
+	Status struct {
+		Value string `json:"value"`
+	} `json:"status"`
+	Rack *struct {
+		Name string `json:"name"`
+	} `json:"rack"`
+	PrimaryIP4 *struct {
+		Address string `json:"address"`
+	} `json:"primary_ip4"`
+	CustomFields struct {
+		DiskCount *uint64        `json:"disk_count"`
+		DiskSize  *misc.ByteSize `json:"disk_size_tb"`
+	} `json:"custom_fields"`
+}
+
+// Servers implements the Source interface
+func (export NetBoxExport) Servers() ([]Server, error) {
+	buf, err := os.ReadFile(export.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// accept a complete API response as well as a plain list of devices
+	var devices []netboxDevice
+	if strings.HasPrefix(strings.TrimSpace(string(buf)), "[") {
+		err = json.Unmarshal(buf, &devices)
+	} else {
+		var response struct {
+			Next     *string        `json:"next"`
+			Previous *string        `json:"previous"`
+			Results  []netboxDevice `json:"results"`
+		}
+		err = json.Unmarshal(buf, &response)
+		if err == nil && (response.Next != nil || response.Previous != nil) {
+			return nil, fmt.Errorf("%s contains only one page of the devices, export all devices at once, e.g. with ?limit=0", export.Filename)
+		}
+		devices = response.Results
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", export.Filename, err)
+	}
+
+	var servers []Server
+	for _, device := range devices {
+		if device.Status.Value != "" && device.Status.Value != "active" {
+			continue
+		}
+		if device.Rack == nil {
+			return nil, fmt.Errorf("device %s has no rack", device.Name)
+		}
+		if device.PrimaryIP4 == nil {
+			return nil, fmt.Errorf("device %s has no primary IPv4 address", device.Name)
+		}
+		prefix, err := netip.ParsePrefix(device.PrimaryIP4.Address)
+		if err != nil {
+			return nil, fmt.Errorf("device %s has an invalid primary IPv4 address: %w", device.Name, err)
+		}
+
+		server := Server{
+			Name: device.Name,
+			Rack: device.Rack.Name,
+			IP:   prefix.Addr().String(),
+		}
+		if device.CustomFields.DiskCount != nil {
+			server.DiskCount = *device.CustomFields.DiskCount
+		}
+		if device.CustomFields.DiskSize != nil {
+			server.DiskSize = *device.CustomFields.DiskSize
+		}
+		servers = append(servers, server)
+	}
+
+	return servers, nil
+}
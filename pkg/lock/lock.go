@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/sapcc/go-bits/logg"
+)
+
+// Holder is the record of the process holding a lock which is stored in the lock file
+type Holder struct {
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// String returns a description of the holder like "alice on node01 (PID 1234) since 2026-01-02 03:04:05"
+func (holder Holder) String() string {
+	if holder.PID == 0 {
+		return "another process"
+	}
+	return fmt.Sprintf("%s on %s (PID %d) since %s", holder.User, holder.Host, holder.PID, holder.Started.Format(time.DateTime))
+}
+
+// currentHolder returns the record of the running process
+func currentHolder() Holder {
+	holder := Holder{User: os.Getenv("USER"), PID: os.Getpid(), Started: time.Now()}
+	if current, err := user.Current(); err == nil {
+		holder.User = current.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		holder.Host = hostname
+	}
+	return holder
+}
+
+// Filename returns the lock file of a builder file
+func Filename(builderFilename string) string {
+	return builderFilename + ".lock"
+}
+
+// Lock is an advisory lock on a builder file. It only protects against other processes which use it as well.
+// The lock is released when it is released explicitly or when the process exits.
+type Lock struct {
+	file *os.File
+}
+
+// ErrLocked is returned by Acquire if the builder file is locked by another process
+type ErrLocked struct {
+	BuilderFilename string
+	Holder          Holder
+}
+
+func (e ErrLocked) Error() string {
+	return fmt.Sprintf("%s is locked by %s", e.BuilderFilename, e.Holder)
+}
+
+// readHolder reads the holder record from the lock file. The record may be missing if the holder just took the lock.
+func readHolder(file *os.File) Holder {
+	var holder Holder
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<20))
+	if err == nil {
+		_ = json.Unmarshal(data, &holder) //nolint:errcheck // an unreadable record is described as "another process"
+	}
+	return holder
+}
+
+// Acquire takes the lock of the builder file and writes the holder record.
+// If another process holds the lock, Acquire fails immediately with ErrLocked unless wait is set,
+// in which case it blocks until the lock is released.
+func Acquire(builderFilename string, wait bool) (*Lock, error) {
+	file, err := os.OpenFile(Filename(builderFilename), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	acquired, err := lockFile(file, false)
+	if err == nil && !acquired {
+		holder := readHolder(file)
+		if !wait {
+			file.Close()
+			return nil, ErrLocked{BuilderFilename: builderFilename, Holder: holder}
+		}
+		logg.Info("Waiting for the lock of %s which is held by %s", builderFilename, holder)
+		_, err = lockFile(file, true)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("locking %s failed: %w", builderFilename, err)
+	}
+
+	data, err := json.Marshal(currentHolder())
+	if err == nil {
+		err = file.Truncate(0)
+	}
+	if err == nil {
+		_, err = file.WriteAt(data, 0)
+	}
+	if err != nil {
+		unlockFile(file) //nolint:errcheck // the write error is more relevant
+		file.Close()
+		return nil, fmt.Errorf("writing %s failed: %w", Filename(builderFilename), err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Release removes the holder record and releases the lock.
+// The lock file itself is kept because other processes might already wait for it.
+func (lock *Lock) Release() error {
+	err := lock.file.Truncate(0)
+	if unlockErr := unlockFile(lock.file); err == nil {
+		err = unlockErr
+	}
+	if closeErr := lock.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
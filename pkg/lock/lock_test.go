@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/errext"
+	"github.com/sapcc/go-bits/must"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	builderFilename := filepath.Join(t.TempDir(), "object.builder")
+
+	first, err := Acquire(builderFilename, false)
+	assert.ErrEqual(t, err, nil)
+
+	// locks are held per open file, so a second acquire in the same process conflicts like another process would
+	_, err = Acquire(builderFilename, false)
+	errLocked, ok := errext.As[ErrLocked](err)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, errLocked.Holder.PID, os.Getpid())
+	assert.Equal(t, errLocked.Holder.User, currentHolder().User)
+	assert.ErrEqual(t, err, builderFilename+" is locked by "+errLocked.Holder.String())
+
+	must.SucceedT(t, first.Release())
+	assert.DeepEqual(t, "lock file after release", must.ReturnT(os.ReadFile(Filename(builderFilename)))(t), []byte{})
+
+	second, err := Acquire(builderFilename, false)
+	assert.ErrEqual(t, err, nil)
+	must.SucceedT(t, second.Release())
+}
+
+func TestAcquireWait(t *testing.T) {
+	builderFilename := filepath.Join(t.TempDir(), "object.builder")
+
+	first, err := Acquire(builderFilename, false)
+	assert.ErrEqual(t, err, nil)
+
+	acquired := make(chan *Lock)
+	go func() {
+		second, err := Acquire(builderFilename, true)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock was acquired while it was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	must.SucceedT(t, first.Release())
+	select {
+	case second := <-acquired:
+		must.SucceedT(t, second.Release())
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not acquired after it was released")
+	}
+}
+
+func TestHolderString(t *testing.T) {
+	assert.Equal(t, Holder{}.String(), "another process")
+	holder := Holder{User: "alice", Host: "node01", PID: 1234, Started: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	assert.Equal(t, holder.String(), "alice on node01 (PID 1234) since 2026-01-02 03:04:05")
+}
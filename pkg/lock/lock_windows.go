@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// lockedRange returns the byte range which is locked. Locks on windows are mandatory, therefore a byte far behind
+// the holder record is locked to keep the record readable for other processes.
+func lockedRange() *syscall.Overlapped {
+	return &syscall.Overlapped{OffsetHigh: 0x7fffffff}
+}
+
+// lockFile takes an exclusive lock on the file. Without wait it returns false if another process holds the lock.
+func lockFile(file *os.File, wait bool) (bool, error) {
+	flags := uintptr(lockfileExclusiveLock)
+	if !wait {
+		flags |= lockfileFailImmediately
+	}
+	r1, _, err := procLockFileEx.Call(file.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(lockedRange())))
+	if r1 != 0 {
+		return true, nil
+	}
+	if errors.Is(err, errorLockViolation) {
+		return false, nil
+	}
+	return false, err
+}
+
+func unlockFile(file *os.File) error {
+	r1, _, err := procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(lockedRange())))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package misc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes which can be given in a human readable form like "7.68TB", "14TiB" or "960GB".
+// SI units (KB, MB, GB, TB, PB) are powers of 1000 and IEC units (KiB, MiB, GiB, TiB, PiB) are powers of 1024.
+// For backwards compatibility a bare number without unit is interpreted as TB.
+type ByteSize uint64
+
+// Common sizes
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1000 * Byte
+	Megabyte          = 1000 * Kilobyte
+	Gigabyte          = 1000 * Megabyte
+	Terabyte          = 1000 * Gigabyte
+	Petabyte          = 1000 * Terabyte
+	Kibibyte          = 1024 * Byte
+	Mebibyte          = 1024 * Kibibyte
+	Gibibyte          = 1024 * Mebibyte
+	Tebibyte          = 1024 * Gibibyte
+	Pebibyte          = 1024 * Tebibyte
+)
+
+// byteSizeUnits is ordered from the largest to the smallest unit, which is the order String() prefers them
+var byteSizeUnits = []struct {
+	Name string
+	Size ByteSize
+}{
+	{"PB", Petabyte}, {"PiB", Pebibyte},
+	{"TB", Terabyte}, {"TiB", Tebibyte},
+	{"GB", Gigabyte}, {"GiB", Gibibyte},
+	{"MB", Megabyte}, {"MiB", Mebibyte},
+	{"KB", Kilobyte}, {"KiB", Kibibyte},
+	{"B", Byte},
+}
+
+// ParseByteSize parses a size like "7.68TB" or "14TiB".
+// The unit prefix is case insensitive but the "B" is required, so that "TB", "tB" and "TiB"
+// are accepted while ambiguous units like "T" or "Tb" (which would be terabits) are rejected.
+func ParseByteSize(str string) (ByteSize, error) {
+	str = strings.TrimSpace(str)
+	idx := strings.IndexFunc(str, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	number, unitName := str, ""
+	if idx != -1 {
+		number, unitName = str[:idx], strings.TrimSpace(str[idx:])
+	}
+	if number == "" {
+		return 0, fmt.Errorf("invalid size %q: missing number", str)
+	}
+
+	unit := Terabyte
+	if unitName != "" {
+		var ok bool
+		unit, ok = parseByteSizeUnit(unitName)
+		if !ok {
+			return 0, fmt.Errorf("invalid size %q: unknown unit %q, must be one of B, KB, MB, GB, TB, PB, KiB, MiB, GiB, TiB or PiB", str, unitName)
+		}
+	}
+
+	// calculate with rationals to not lose precision on sizes like 7.68TB
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: %q is not a number", str, number)
+	}
+	value.Mul(value, new(big.Rat).SetUint64(uint64(unit)))
+	if !value.IsInt() {
+		return 0, fmt.Errorf("invalid size %q: not a whole number of bytes", str)
+	}
+	if !value.Num().IsUint64() {
+		return 0, fmt.Errorf("invalid size %q: too large", str)
+	}
+	return ByteSize(value.Num().Uint64()), nil
+}
+
+func parseByteSizeUnit(unitName string) (ByteSize, bool) {
+	// a lower case b would be bits
+	prefix, ok := strings.CutSuffix(unitName, "B")
+	if !ok {
+		return 0, false
+	}
+	if prefix == "" {
+		return Byte, true
+	}
+	prefix, iec := strings.CutSuffix(prefix, "i")
+	for _, unit := range byteSizeUnits {
+		if unit.Size != Byte && strings.EqualFold(prefix, unit.Name[:1]) && iec == strings.Contains(unit.Name, "i") {
+			return unit.Size, true
+		}
+	}
+	return 0, false
+}
+
+// String formats the size with the unit that gives the shortest exact representation.
+func (s ByteSize) String() string {
+	if s == 0 {
+		return "0B"
+	}
+	result := ""
+	for _, unit := range byteSizeUnits {
+		if s < unit.Size {
+			continue
+		}
+		formatted := new(big.Rat).SetFrac(new(big.Int).SetUint64(uint64(s)), new(big.Int).SetUint64(uint64(unit.Size))).FloatString(12)
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".") + unit.Name
+		// FloatString rounds, make sure that the size survives a round trip
+		if parsed, err := ParseByteSize(formatted); err != nil || parsed != s {
+			continue
+		}
+		if result == "" || len(formatted) < len(result) {
+			result = formatted
+		}
+	}
+	return result
+}
+
+// TB returns the size in terabytes
+func (s ByteSize) TB() float64 {
+	return float64(s) / float64(Terabyte)
+}
+
+// GB returns the size in gigabytes
+func (s ByteSize) GB() float64 {
+	return float64(s) / float64(Gigabyte)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (s ByteSize) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *ByteSize) UnmarshalYAML(unmarshal func(any) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	size, err := ParseByteSize(str)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Numbers are interpreted as TB.
+func (s *ByteSize) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("invalid size %s: must be a number or a string", string(data))
+		}
+		str = number.String()
+	}
+	size, err := ParseByteSize(str)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// Set implements the pflag.Value interface.
+func (s *ByteSize) Set(str string) error {
+	size, err := ParseByteSize(str)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *ByteSize) Type() string {
+	return "size"
+}
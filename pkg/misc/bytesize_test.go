@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package misc
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseByteSize(t *testing.T) {
+	testCases := map[string]ByteSize{
+		"7.68TB": 7_680_000_000_000,
+		"14TiB":  14 << 40,
+		"960GB":  960_000_000_000,
+		"960 GB": 960_000_000_000,
+		"1.5kB":  1500,
+		"512B":   512,
+		"6":      6_000_000_000_000,
+		"0.5":    500_000_000_000,
+	}
+	for input, expected := range testCases {
+		size, err := ParseByteSize(input)
+		assert.ErrEqual(t, err, nil)
+		assert.Equal(t, size, expected)
+	}
+
+	for input, message := range map[string]string{
+		"6T":      `invalid size "6T": unknown unit "T", must be one of B, KB, MB, GB, TB, PB, KiB, MiB, GiB, TiB or PiB`,
+		"6Tb":     `invalid size "6Tb": unknown unit "Tb", must be one of B, KB, MB, GB, TB, PB, KiB, MiB, GiB, TiB or PiB`,
+		"TB":      `invalid size "TB": missing number`,
+		"-1TB":    `invalid size "-1TB": missing number`,
+		"1.2.3":   `invalid size "1.2.3": "1.2.3" is not a number`,
+		"0.5B":    `invalid size "0.5B": not a whole number of bytes`,
+		"99999PB": `invalid size "99999PB": too large`,
+	} {
+		_, err := ParseByteSize(input)
+		assert.ErrEqual(t, err, message)
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	assert.Equal(t, ByteSize(7_680_000_000_000).String(), "7.68TB")
+	assert.Equal(t, ByteSize(14<<40).String(), "14TiB")
+	assert.Equal(t, ByteSize(960_000_000_000).String(), "960GB")
+	assert.Equal(t, ByteSize(1536).String(), "1536B")
+	assert.Equal(t, (3 * Tebibyte / 2).String(), "1.5TiB")
+	assert.Equal(t, ByteSize(1).String(), "1B")
+	assert.Equal(t, ByteSize(0).String(), "0B")
+}
+
+func TestByteSizeYAML(t *testing.T) {
+	var sizes struct {
+		Legacy ByteSize `yaml:"legacy"`
+		SI     ByteSize `yaml:"si"`
+		IEC    ByteSize `yaml:"iec"`
+	}
+	err := yaml.UnmarshalStrict([]byte("legacy: 7.68\nsi: 960GB\niec: 14TiB\n"), &sizes)
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, sizes.Legacy, 7680*Gigabyte)
+	assert.Equal(t, sizes.SI, 960*Gigabyte)
+	assert.Equal(t, sizes.IEC, 14*Tebibyte)
+
+	data, err := yaml.Marshal(sizes)
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, string(data), "legacy: 7.68TB\nsi: 960GB\niec: 14TiB\n")
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package recon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Disk is a device as reported by the recon middleware of a storage node
+type Disk struct {
+	Device  string
+	Mounted bool
+	// Size is zero for unmounted disks
+	Size uint64
+}
+
+// Snapshot contains the disks reported by recon per node IP
+type Snapshot map[string][]Disk
+
+// reconDisk is an entry of the /recon/unmounted and /recon/diskusage endpoints.
+// For unmounted disks mounted can be an error message instead of false and size is an empty string.
+type reconDisk struct {
+	Device  string `json:"device"`
+	Mounted any    `json:"mounted"`
+	Size    any    `json:"size"`
+}
+
+// ReadSnapshot reads a JSON file which contains the output of the recon endpoint /recon/unmounted or /recon/diskusage
+// for every storage node, keyed by the node IP with an optional port like
+//
+//	{"10.114.1.202:6000": [{"device": "swift-02", "mounted": false}]}
+func ReadSnapshot(filename string) (Snapshot, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string][]reconDisk
+	if err := json.Unmarshal(buf, &data); err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", filename, err)
+	}
+
+	snapshot := make(Snapshot, len(data))
+	for host, disks := range data {
+		nodeIP := host
+		if ip, _, err := net.SplitHostPort(host); err == nil {
+			nodeIP = ip
+		}
+		if net.ParseIP(nodeIP) == nil {
+			return nil, fmt.Errorf("%s contains the invalid node IP %q", filename, host)
+		}
+
+		for _, disk := range disks {
+			mounted, ok := disk.Mounted.(bool)
+			size, _ := disk.Size.(float64) //nolint:errcheck // empty string for unmounted disks
+			snapshot[nodeIP] = append(snapshot[nodeIP], Disk{
+				Device: disk.Device,
+				// recon reports errors while checking the mount point as string
+				Mounted: ok && mounted,
+				Size:    uint64(size),
+			})
+		}
+		slices.SortFunc(snapshot[nodeIP], func(a, b Disk) int {
+			return strings.Compare(a.Device, b.Device)
+		})
+	}
+
+	return snapshot, nil
+}
+
+// Merge combines two snapshots. Disks which are reported as unmounted by either snapshot are considered unmounted.
+func (snapshot Snapshot) Merge(other Snapshot) Snapshot {
+	merged := make(Snapshot, len(snapshot))
+	for _, s := range []Snapshot{snapshot, other} {
+		for nodeIP, disks := range s {
+			for _, disk := range disks {
+				idx := slices.IndexFunc(merged[nodeIP], func(d Disk) bool { return d.Device == disk.Device })
+				if idx == -1 {
+					merged[nodeIP] = append(merged[nodeIP], disk)
+					continue
+				}
+				existing := &merged[nodeIP][idx]
+				existing.Mounted = existing.Mounted && disk.Mounted
+				existing.Size = max(existing.Size, disk.Size)
+			}
+		}
+	}
+	for nodeIP := range merged {
+		slices.SortFunc(merged[nodeIP], func(a, b Disk) int {
+			return strings.Compare(a.Device, b.Device)
+		})
+	}
+	return merged
+}
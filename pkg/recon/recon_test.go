@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package recon
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/ruleedit"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+func readTestSnapshot(t *testing.T) Snapshot {
+	t.Helper()
+
+	unmounted, err := ReadSnapshot("../../testing/recon-unmounted-1.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	diskUsage, err := ReadSnapshot("../../testing/recon-diskusage-1.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return unmounted.Merge(diskUsage)
+}
+
+func TestReadSnapshot(t *testing.T) {
+	snapshot := readTestSnapshot(t)
+
+	assert.DeepEqual(t, "snapshot", snapshot, Snapshot{
+		"10.114.1.202": {
+			{Device: "swift-01", Mounted: true, Size: 6001175126016},
+			{Device: "swift-02", Mounted: true, Size: 6001175126016},
+			{Device: "swift-03", Mounted: false},
+		},
+		"10.114.1.203": {
+			{Device: "swift-01", Mounted: true, Size: 6001175126016},
+			{Device: "swift-02", Mounted: true, Size: 6001175126016},
+			{Device: "swift-04", Mounted: true, Size: 6001175126016},
+		},
+		"10.114.1.250": {
+			{Device: "swift-01", Mounted: false},
+		},
+	})
+}
+
+func TestSync(t *testing.T) {
+	var ring builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &ring)
+
+	var ringRules rules.RingRules
+	misc.ReadYAML("../../testing/artisan-broken-1.yaml", &ringRules)
+
+	report := Sync(ringRules, ring, readTestSnapshot(t), true)
+	assert.DeepEqual(t, "report", report, Report{
+		UnmountedDisks: map[string][]string{"10.114.1.202": {"swift-03"}},
+		RecoveredDisks: map[string][]string{"10.114.1.203": {"swift-02"}},
+		DiskCounts:     map[string]uint64{"10.114.1.203": 4},
+		NotReported: []string{
+			"disk swift-03 on node 10.114.1.203 is expected but not reported by recon",
+		},
+		MissingFromRules: []string{
+			"disk swift-04 on node 10.114.1.203 is reported by recon but missing in the rules",
+			"node 10.114.1.250 is reported by recon but missing in the rules",
+		},
+	})
+
+	// the rules of artisan-broken-1.yaml with comments and anchors which are kept
+	editor := must.Return(ruleedit.Parse([]byte(`object.builder:
+  base_port: 6001
+  base_size_tb: 6
+  region: 1
+  zones:
+    1:
+      nodes:
+        # replaced swift-03 in 2025
+        10.114.1.202:
+          disk_count: 3
+          weight: &weight 100
+        10.114.1.203:
+          disk_count: 3
+          weight: *weight
+          broken_disks: [swift-02] # waiting for a replacement
+`)))
+	assert.ErrEqual(t, report.Apply(editor, "object.builder", false), nil)
+	assert.Equal(t, string(must.Return(editor.Bytes())), `object.builder:
+  base_port: 6001
+  base_size_tb: 6
+  region: 1
+  zones:
+    1:
+      nodes:
+        # replaced swift-03 in 2025
+        10.114.1.202:
+          disk_count: 3
+          weight: &weight 100
+          zero_weight_disks:
+            - swift-03
+        10.114.1.203:
+          disk_count: 4
+          weight: *weight
+          broken_disks: [] # waiting for a replacement
+`)
+}
+
+func TestSyncUnmountedOnly(t *testing.T) {
+	var ring builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &ring)
+
+	var ringRules rules.RingRules
+	misc.ReadYAML("../../testing/artisan-broken-1.yaml", &ringRules)
+
+	unmounted, err := ReadSnapshot("../../testing/recon-unmounted-1.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// /recon/unmounted does not list mounted disks, so they are not reported as missing
+	report := Sync(ringRules, ring, unmounted, false)
+	assert.DeepEqual(t, "report", report, Report{
+		UnmountedDisks: map[string][]string{"10.114.1.202": {"swift-03"}},
+		RecoveredDisks: map[string][]string{},
+		DiskCounts:     map[string]uint64{},
+	})
+}
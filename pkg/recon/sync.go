@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package recon
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/ruleedit"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// Report contains the differences between the disks reported by recon, the rules and the ring
+type Report struct {
+	// UnmountedDisks are unmounted disks which are neither listed in zero_weight_disks nor in broken_disks, keyed by node IP
+	UnmountedDisks map[string][]string
+	// RecoveredDisks are disks listed in zero_weight_disks or broken_disks which recon reports as mounted again, keyed by node IP
+	RecoveredDisks map[string][]string
+	// DiskCounts are the disk counts of nodes on which recon reports more disks than disk_count, keyed by node IP
+	DiskCounts map[string]uint64
+	// NotReported lists disks in the ring or the rules which recon does not know about
+	NotReported []string
+	// MissingFromRules lists disks which recon reports but which are missing in the rules
+	MissingFromRules []string
+}
+
+// IsEmpty returns true if recon, the rules and the ring agree with each other
+func (report Report) IsEmpty() bool {
+	return len(report.UnmountedDisks) == 0 && len(report.RecoveredDisks) == 0 && len(report.DiskCounts) == 0 &&
+		len(report.NotReported) == 0 && len(report.MissingFromRules) == 0
+}
+
+// Sync compares a recon snapshot with the rules and the ring.
+// complete is false if the snapshot only contains the output of /recon/unmounted which lists no mounted disks.
+// Then disks which are expected but not reported by recon cannot be detected and NotReported stays empty.
+func Sync(ringRules rules.RingRules, ring builderfile.RingInfo, snapshot Snapshot, complete bool) Report {
+	report := Report{
+		UnmountedDisks: make(map[string][]string),
+		RecoveredDisks: make(map[string][]string),
+		DiskCounts:     make(map[string]uint64),
+	}
+
+	for _, nodeIP := range slices.Sorted(maps.Keys(snapshot)) {
+		_, nodeRules, ok := ringRules.FindNode(nodeIP)
+		if !ok {
+			report.MissingFromRules = append(report.MissingFromRules, fmt.Sprintf("node %s is reported by recon but missing in the rules", nodeIP))
+			continue
+		}
+
+		var maxDiskNumber uint64
+		for _, disk := range snapshot[nodeIP] {
+			diskNumber, ok := rules.ParseDiskNumber(disk.Device)
+			if !ok {
+				report.MissingFromRules = append(report.MissingFromRules, fmt.Sprintf("disk %s on node %s is reported by recon but cannot be expressed in the rules", disk.Device, nodeIP))
+				continue
+			}
+			if diskNumber > nodeRules.DiskCount {
+				report.MissingFromRules = append(report.MissingFromRules, fmt.Sprintf("disk %s on node %s is reported by recon but missing in the rules", disk.Device, nodeIP))
+				maxDiskNumber = max(maxDiskNumber, diskNumber)
+			}
+
+			isListed := slices.Contains(nodeRules.ZeroWeightDisks, disk.Device) || slices.Contains(nodeRules.BrokenDisks, disk.Device)
+			switch {
+			case !disk.Mounted && !isListed:
+				report.UnmountedDisks[nodeIP] = append(report.UnmountedDisks[nodeIP], disk.Device)
+			case disk.Mounted && isListed:
+				report.RecoveredDisks[nodeIP] = append(report.RecoveredDisks[nodeIP], disk.Device)
+			}
+		}
+		if maxDiskNumber > 0 {
+			report.DiskCounts[nodeIP] = maxDiskNumber
+		}
+	}
+
+	if !complete {
+		return report
+	}
+
+	// disks that are expected by the rules or the ring but are unknown to recon
+	expected := make(map[string][]string)
+	for _, device := range ring.Devices {
+		if !slices.Contains(expected[device.NodeIP], device.Name) {
+			expected[device.NodeIP] = append(expected[device.NodeIP], device.Name)
+		}
+	}
+	for nodeIP := range snapshot {
+		_, nodeRules, ok := ringRules.FindNode(nodeIP)
+		if !ok {
+			continue
+		}
+		for diskNumber := uint64(1); diskNumber <= nodeRules.DiskCount; diskNumber++ {
+			diskName := rules.DiskName(diskNumber)
+			if !slices.Contains(nodeRules.BrokenDisks, diskName) && !slices.Contains(expected[nodeIP], diskName) {
+				expected[nodeIP] = append(expected[nodeIP], diskName)
+			}
+		}
+	}
+	for _, nodeIP := range slices.Sorted(maps.Keys(expected)) {
+		disks, ok := snapshot[nodeIP]
+		if !ok {
+			report.NotReported = append(report.NotReported, fmt.Sprintf("node %s is in the ring but not reported by recon", nodeIP))
+			continue
+		}
+		diskNames := expected[nodeIP]
+		sort.Strings(diskNames)
+		for _, diskName := range diskNames {
+			if !slices.ContainsFunc(disks, func(d Disk) bool { return d.Device == diskName }) {
+				report.NotReported = append(report.NotReported, fmt.Sprintf("disk %s on node %s is expected but not reported by recon", diskName, nodeIP))
+			}
+		}
+	}
+
+	return report
+}
+
+// Apply changes the rule file according to the proposals of the report.
+// The proposals are applied to the nodes themselves, so that profiles and defaults are kept.
+// Unmounted disks are added to zero_weight_disks instead of broken_disks, so that they stay in the ring with weight 0
+// and their replicas are moved elsewhere until they are replaced, like the swift documentation recommends.
+// If propagate is true, nodes which are shared with other nodes through an anchor are changed for all of them.
+func (report Report) Apply(editor *ruleedit.Editor, ringName string, propagate bool) error {
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.DiskCounts)) {
+		if err := editor.SetDiskCount(ringName, nodeIP, report.DiskCounts[nodeIP], propagate); err != nil {
+			return err
+		}
+	}
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.UnmountedDisks)) {
+		if err := editor.AddZeroWeightDisks(ringName, nodeIP, report.UnmountedDisks[nodeIP], propagate); err != nil {
+			return err
+		}
+	}
+	for _, nodeIP := range slices.Sorted(maps.Keys(report.RecoveredDisks)) {
+		if err := editor.RemoveDisks(ringName, nodeIP, report.RecoveredDisks[nodeIP], propagate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
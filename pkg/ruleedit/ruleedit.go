@@ -0,0 +1,370 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ruleedit changes rule files in place while keeping their comments, anchors, aliases and key order.
+package ruleedit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// keys of a rule file which do not contain the rules of a ring, see rules.File
+const (
+	includeKey  = "include"
+	profilesKey = "profiles"
+)
+
+// Editor holds the node tree of a rule file
+type Editor struct {
+	document yaml.Node
+}
+
+// Parse reads the content of a rule file.
+// Templates are rejected because the changed file would lose the template source.
+func Parse(data []byte) (*Editor, error) {
+	if rules.IsTemplate(data) {
+		return nil, errors.New("rule file templates cannot be changed, change the template source manually")
+	}
+	var editor Editor
+	if err := yaml.Unmarshal(data, &editor.document); err != nil {
+		return nil, err
+	}
+	if editor.root() == nil {
+		return nil, errors.New("rule file needs to contain a mapping of builder files to rules")
+	}
+	return &editor, nil
+}
+
+// File reads a rule file
+func File(filename string) (*Editor, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	editor, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", filename, err)
+	}
+	return editor, nil
+}
+
+// Bytes encodes the rule file again
+func (e *Editor) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&e.document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RingNames returns the builder file names in the order of the rule file
+func (e *Editor) RingNames() []string {
+	var ringNames []string
+	root := e.root()
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if key := root.Content[i].Value; key != includeKey && key != profilesKey {
+			ringNames = append(ringNames, root.Content[i].Value)
+		}
+	}
+	return ringNames
+}
+
+// AddNode adds a node to a zone of a ring. The zone is created if it does not exist yet.
+func (e *Editor) AddNode(ringName string, zone uint64, nodeIP string, nodeRules rules.NodeRules) error {
+	ring, err := e.ring(ringName)
+	if err != nil {
+		return err
+	}
+	if _, _, err := e.findNode(ringName, nodeIP); err == nil {
+		return fmt.Errorf("node %s already exists in %s", nodeIP, ringName)
+	}
+
+	zones := mappingValue(ring, "zones")
+	if zones == nil {
+		zones = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(ring, "zones", zones)
+	}
+	zoneKey := strconv.FormatUint(zone, 10)
+	zoneNode := mappingValue(zones, zoneKey)
+	if zoneNode == nil {
+		zoneNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		zones.Content = append(zones.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: zoneKey}, zoneNode)
+	}
+	if zoneNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("zone %d of %s cannot be changed because it is not a mapping", zone, ringName)
+	}
+	nodes := mappingValue(zoneNode, "nodes")
+	if nodes == nil {
+		nodes = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(zoneNode, "nodes", nodes)
+	}
+
+	var value yaml.Node
+	if err := value.Encode(nodeRules); err != nil {
+		return err
+	}
+	nodes.Content = append(nodes.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: nodeIP}, &value)
+	return nil
+}
+
+// SetWeight sets an explicit weight for all disks of a node.
+// If propagate is true, a node which is shared with other nodes through an anchor is changed for all of them.
+func (e *Editor) SetWeight(ringName, nodeIP string, weight float64, propagate bool) error {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(weight, 'f', -1, 64)}
+	if weight == float64(int64(weight)) {
+		value.Tag = "!!int"
+	}
+	return e.setNodeValue(ringName, nodeIP, "weight", value, propagate)
+}
+
+// SetDiskCount sets the number of disks of a node.
+// If propagate is true, a node which is shared with other nodes through an anchor is changed for all of them.
+func (e *Editor) SetDiskCount(ringName, nodeIP string, diskCount uint64, propagate bool) error {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(diskCount, 10)}
+	return e.setNodeValue(ringName, nodeIP, "disk_count", value, propagate)
+}
+
+// MarkBroken adds disks like "swift-02" to the broken disks of a node.
+// If propagate is true, a node which is shared with other nodes through an anchor is changed for all of them.
+func (e *Editor) MarkBroken(ringName, nodeIP string, diskNames []string, propagate bool) error {
+	return e.addDisks(ringName, nodeIP, "broken_disks", diskNames, propagate)
+}
+
+// AddZeroWeightDisks adds disks like "swift-02" to the disks of a node which stay in the ring with weight 0.
+// If propagate is true, a node which is shared with other nodes through an anchor is changed for all of them.
+func (e *Editor) AddZeroWeightDisks(ringName, nodeIP string, diskNames []string, propagate bool) error {
+	return e.addDisks(ringName, nodeIP, "zero_weight_disks", diskNames, propagate)
+}
+
+// RemoveDisks removes disks like "swift-02" from the broken disks and the zero weight disks of a node.
+// A list which becomes empty is kept as an empty list, so that the disks of the defaults or the profile do not apply again.
+// If propagate is true, a node which is shared with other nodes through an anchor is changed for all of them.
+func (e *Editor) RemoveDisks(ringName, nodeIP string, diskNames []string, propagate bool) error {
+	nodeRules, err := e.resolvedNode(ringName, nodeIP)
+	if err != nil {
+		return err
+	}
+
+	lists := []struct {
+		key   string
+		disks []string
+	}{
+		{"broken_disks", nodeRules.BrokenDisks},
+		{"zero_weight_disks", nodeRules.ZeroWeightDisks},
+	}
+	for _, list := range lists {
+		remaining := slices.DeleteFunc(slices.Clone(list.disks), func(diskName string) bool {
+			return slices.Contains(diskNames, diskName)
+		})
+		if len(remaining) == len(list.disks) {
+			continue
+		}
+		if err := e.setNodeValue(ringName, nodeIP, list.key, diskList(remaining), propagate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDisks adds disks to a list of disks of a node after checking that the node has them
+func (e *Editor) addDisks(ringName, nodeIP, key string, diskNames []string, propagate bool) error {
+	nodeRules, err := e.resolvedNode(ringName, nodeIP)
+	if err != nil {
+		return err
+	}
+
+	list := nodeRules.BrokenDisks
+	if key == "zero_weight_disks" {
+		list = nodeRules.ZeroWeightDisks
+	}
+	for _, diskName := range diskNames {
+		diskNumber, ok := rules.ParseDiskNumber(diskName)
+		if !ok || diskNumber > nodeRules.DiskCount {
+			return fmt.Errorf("node %s in %s has no disk %s", nodeIP, ringName, diskName)
+		}
+		if !slices.Contains(list, diskName) {
+			list = append(list, diskName)
+		}
+	}
+	return e.setNodeValue(ringName, nodeIP, key, diskList(list), propagate)
+}
+
+// diskList returns a sequence of disk names. An empty list is written in flow style as [].
+func diskList(diskNames []string) *yaml.Node {
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if len(diskNames) == 0 {
+		value.Style = yaml.FlowStyle
+	}
+	for _, diskName := range diskNames {
+		value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: diskName})
+	}
+	return value
+}
+
+// setNodeValue sets a key of a node.
+// An alias is replaced by a mapping which merges the anchored node and overrides the key,
+// so that the other nodes which refer to the same anchor keep their values.
+func (e *Editor) setNodeValue(ringName, nodeIP, key string, value *yaml.Node, propagate bool) error {
+	nodes, node, err := e.findNode(ringName, nodeIP)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case node.Kind == yaml.AliasNode && propagate:
+		setMappingValue(node.Alias, key, value)
+	case node.Kind == yaml.AliasNode:
+		override := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "<<"}, node,
+		}}
+		setMappingValue(override, key, value)
+		setMappingValue(nodes, nodeIP, override)
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+		// a node without own values which only uses the defaults
+		setMappingValue(nodes, nodeIP, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value,
+		}})
+	case node.Kind != yaml.MappingNode:
+		return fmt.Errorf("node %s in %s cannot be changed because it is not a mapping", nodeIP, ringName)
+	case node.Anchor != "" && e.hasAlias(node) && !propagate:
+		return fmt.Errorf("node %s in %s is shared with other nodes through the anchor &%s, use --propagate to change all of them", nodeIP, ringName, node.Anchor)
+	default:
+		setMappingValue(node, key, value)
+	}
+	return nil
+}
+
+// resolvedNode returns the rules of a node including the values of its profile and the defaults
+func (e *Editor) resolvedNode(ringName, nodeIP string) (rules.NodeRules, error) {
+	if _, _, err := e.findNode(ringName, nodeIP); err != nil {
+		return rules.NodeRules{}, err
+	}
+	var file rules.File
+	if err := e.document.Decode(&file); err != nil {
+		return rules.NodeRules{}, err
+	}
+	ringRules, err := file.Rings[ringName].Resolve(file.Profiles)
+	if err != nil {
+		return rules.NodeRules{}, err
+	}
+	_, nodeRules, _ := ringRules.FindNode(nodeIP)
+	return *nodeRules, nil
+}
+
+// findNode returns the mapping with the nodes of a zone and the node with the given IP
+func (e *Editor) findNode(ringName, nodeIP string) (nodes, node *yaml.Node, err error) {
+	ring, err := e.ring(ringName)
+	if err != nil {
+		return nil, nil, err
+	}
+	zones := mappingValue(ring, "zones")
+	if zones == nil {
+		return nil, nil, fmt.Errorf("node %s does not exist in %s", nodeIP, ringName)
+	}
+	for i := 1; i < len(zones.Content); i += 2 {
+		nodes := mappingValue(resolve(zones.Content[i]), "nodes")
+		if nodes == nil {
+			continue
+		}
+		if node := mappingValue(nodes, nodeIP); node != nil {
+			return nodes, node, nil
+		}
+	}
+
+	// a node which is covered by a node range gets an exception which overrides the values of the range
+	var file rules.File
+	if err := e.document.Decode(&file); err != nil {
+		return nil, nil, err
+	}
+	if zone, _, ok := file.Rings[ringName].FindNode(nodeIP); ok {
+		nodes := mappingValue(resolve(mappingValue(zones, strconv.FormatUint(zone, 10))), "nodes")
+		if nodes != nil {
+			return nodes, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("node %s does not exist in %s", nodeIP, ringName)
+}
+
+func (e *Editor) ring(ringName string) (*yaml.Node, error) {
+	ring := resolve(mappingValue(e.root(), ringName))
+	if ringName == includeKey || ringName == profilesKey || ring == nil || ring.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("rule file does not contain rules for %s", ringName)
+	}
+	return ring, nil
+}
+
+func (e *Editor) root() *yaml.Node {
+	if len(e.document.Content) != 1 || e.document.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return e.document.Content[0]
+}
+
+// hasAlias reports whether any alias in the document refers to the anchored node
+func (e *Editor) hasAlias(anchored *yaml.Node) bool {
+	var walk func(node *yaml.Node) bool
+	walk = func(node *yaml.Node) bool {
+		if node.Kind == yaml.AliasNode && node.Alias == anchored {
+			return true
+		}
+		return slices.ContainsFunc(node.Content, walk)
+	}
+	return walk(&e.document)
+}
+
+// resolve follows aliases
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingValue returns the value of a key in a mapping node or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value of a key in place or appends the key if it does not exist yet.
+// Comments of the replaced value are kept.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			old := mapping.Content[i+1]
+			if value.LineComment == "" {
+				value.LineComment = old.LineComment
+			}
+			if value.HeadComment == "" {
+				value.HeadComment = old.HeadComment
+			}
+			if value.FootComment == "" {
+				value.FootComment = old.FootComment
+			}
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
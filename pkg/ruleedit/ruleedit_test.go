@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package ruleedit
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+const ringName = "object.builder"
+
+func TestEditAlias(t *testing.T) {
+	editor := must.Return(File("../../testing/artisan-rules-edit.yaml"))
+
+	assert.ErrEqual(t, editor.SetWeight(ringName, "10.114.1.203", 0, false), nil)
+	assert.ErrEqual(t, editor.MarkBroken(ringName, "10.114.1.203", []string{"swift-02"}, false), nil)
+	assert.ErrEqual(t, editor.SetWeight(ringName, "10.114.1.204", 50, false), nil)
+	assert.ErrEqual(t, editor.AddNode(ringName, 3, "10.114.1.205", rules.NodeRules{DiskCount: 4, DiskSize: 14 * misc.Tebibyte}), nil)
+
+	data := must.Return(editor.Bytes())
+	assert.Equal(t, string(data), `# rules for the object ring
+object.builder:
+  base_port: 6001
+  base_size_tb: 6 # all disks are 6TB
+  region: 1
+  zones:
+    1:
+      nodes:
+        # first rack
+        10.114.1.202: &default
+          disk_count: 3
+          disk_size_tb: 6
+        10.114.1.203:
+          <<: *default
+          weight: 0
+          broken_disks:
+            - swift-02
+    2:
+      nodes:
+        10.114.1.204:
+          disk_count: 3
+          weight: 50 # draining soon
+    3:
+      nodes:
+        10.114.1.205:
+          disk_count: 4
+          disk_size: 14TiB
+`)
+
+	// the result needs to be readable by apply
+	var file map[string]rules.RingRules
+	assert.ErrEqual(t, yaml.UnmarshalStrict(data, &file), nil)
+	weight := 0.0
+	assert.DeepEqual(t, "node", *file[ringName].Zones[1].Nodes["10.114.1.203"], rules.NodeRules{
+		DiskCount:   3,
+		DiskSize:    6 * misc.Terabyte,
+		Weight:      &weight,
+		BrokenDisks: []string{"swift-02"},
+	})
+	assert.DeepEqual(t, "node", *file[ringName].Zones[1].Nodes["10.114.1.202"], rules.NodeRules{
+		DiskCount: 3,
+		DiskSize:  6 * misc.Terabyte,
+	})
+}
+
+func TestEditAnchor(t *testing.T) {
+	editor := must.Return(File("../../testing/artisan-rules-edit.yaml"))
+
+	assert.ErrEqual(t, editor.SetWeight(ringName, "10.114.1.202", 0, false),
+		"node 10.114.1.202 in object.builder is shared with other nodes through the anchor &default, use --propagate to change all of them")
+	assert.ErrEqual(t, editor.MarkBroken(ringName, "10.114.1.203", []string{"swift-01"}, true), nil)
+
+	var file map[string]rules.RingRules
+	assert.ErrEqual(t, yaml.UnmarshalStrict(must.Return(editor.Bytes()), &file), nil)
+	for _, nodeIP := range []string{"10.114.1.202", "10.114.1.203"} {
+		assert.DeepEqual(t, "broken disks", file[ringName].Zones[1].Nodes[nodeIP].BrokenDisks, []string{"swift-01"})
+	}
+}
+
+func TestEditErrors(t *testing.T) {
+	editor := must.Return(File("../../testing/artisan-rules-edit.yaml"))
+
+	assert.ErrEqual(t, editor.SetWeight("account.builder", "10.114.1.202", 0, false), "rule file does not contain rules for account.builder")
+	assert.ErrEqual(t, editor.SetWeight(ringName, "10.114.1.250", 0, false), "node 10.114.1.250 does not exist in object.builder")
+	assert.ErrEqual(t, editor.MarkBroken(ringName, "10.114.1.204", []string{"swift-04"}, false), "node 10.114.1.204 in object.builder has no disk swift-04")
+	assert.ErrEqual(t, editor.AddNode(ringName, 2, "10.114.1.202", rules.NodeRules{DiskCount: 1}), "node 10.114.1.202 already exists in object.builder")
+
+	_, err := File("../../testing/artisan-rules-template.yaml")
+	assert.ErrEqual(t, err, "parsing ../../testing/artisan-rules-template.yaml failed: rule file templates cannot be changed, change the template source manually")
+}
+
+func TestEditProfiles(t *testing.T) {
+	editor := must.Return(File("../../testing/artisan-rules-profiles.yaml"))
+	assert.DeepEqual(t, "ring names", editor.RingNames(), []string{ringName})
+
+	// the disk count comes from the profile
+	assert.ErrEqual(t, editor.MarkBroken(ringName, "10.114.1.202", []string{"swift-40"}, false), nil)
+	assert.ErrEqual(t, editor.MarkBroken(ringName, "10.114.1.204", []string{"swift-13"}, false), "node 10.114.1.204 in object.builder has no disk swift-13")
+
+	var file rules.File
+	assert.ErrEqual(t, yaml.UnmarshalStrict(must.Return(editor.Bytes()), &file), nil)
+	rings := must.Return(file.Resolve())
+	assert.DeepEqual(t, "broken disks", rings[ringName].Zones[1].Nodes["10.114.1.202"].BrokenDisks, []string{"swift-40"})
+	assert.Equal(t, rings[ringName].Zones[1].Nodes["10.114.1.202"].DiskCount, 40)
+}
+
+func TestEditNodeRange(t *testing.T) {
+	editor := must.Return(Parse([]byte(`object.builder:
+  zones:
+    1:
+      nodes:
+        10.114.1.202-10.114.1.204:
+          disk_count: 3
+`)))
+
+	assert.ErrEqual(t, editor.SetWeight(ringName, "10.114.1.203", 0, false), nil)
+	assert.ErrEqual(t, editor.AddNode(ringName, 1, "10.114.1.204", rules.NodeRules{DiskCount: 1}), "node 10.114.1.204 already exists in object.builder")
+	assert.Equal(t, string(must.Return(editor.Bytes())), `object.builder:
+  zones:
+    1:
+      nodes:
+        10.114.1.202-10.114.1.204:
+          disk_count: 3
+        10.114.1.203:
+          weight: 0
+`)
+}
+
+func TestEditDiskLists(t *testing.T) {
+	editor := must.Return(Parse([]byte(`object.builder:
+  defaults:
+    broken_disks: [swift-01]
+  zones:
+    1:
+      nodes:
+        10.114.1.202:
+          disk_count: 3
+`)))
+
+	assert.ErrEqual(t, editor.AddZeroWeightDisks(ringName, "10.114.1.202", []string{"swift-04"}, false), "node 10.114.1.202 in object.builder has no disk swift-04")
+	assert.ErrEqual(t, editor.SetDiskCount(ringName, "10.114.1.202", 4, false), nil)
+	assert.ErrEqual(t, editor.AddZeroWeightDisks(ringName, "10.114.1.202", []string{"swift-04"}, false), nil)
+	// the broken disk of the defaults is overridden with an empty list
+	assert.ErrEqual(t, editor.RemoveDisks(ringName, "10.114.1.202", []string{"swift-01", "swift-04"}, false), nil)
+	assert.Equal(t, string(must.Return(editor.Bytes())), `object.builder:
+  defaults:
+    broken_disks: [swift-01]
+  zones:
+    1:
+      nodes:
+        10.114.1.202:
+          disk_count: 4
+          zero_weight_disks: []
+          broken_disks: []
+`)
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+)
+
+// DriftCategory groups the differences between the rules and a ring
+type DriftCategory string
+
+const (
+	// DriftMissingDevice is a disk of the rules which is not in the ring
+	DriftMissingDevice DriftCategory = "missing_device"
+	// DriftUnexpectedDevice is a device of the ring which is not covered by the rules
+	DriftUnexpectedDevice DriftCategory = "unexpected_device"
+	// DriftWeight is a device whose weight differs from the desired weight
+	DriftWeight DriftCategory = "weight"
+	// DriftMeta is a device whose meta differs from the rules
+	DriftMeta DriftCategory = "meta"
+	// DriftOverload is an overload of the ring which differs from the rules
+	DriftOverload DriftCategory = "overload"
+	// DriftZoneMismatch is a device which is in a different zone than in the rules
+	DriftZoneMismatch DriftCategory = "zone_mismatch"
+	// DriftPortMismatch is a device which uses a different port than in the rules
+	DriftPortMismatch DriftCategory = "port_mismatch"
+	// DriftSetting is a ring setting like replicas, min_part_hours or part_power which differs from the rules
+	DriftSetting DriftCategory = "setting"
+)
+
+// DriftCategories lists all categories in the order in which they are reported
+var DriftCategories = []DriftCategory{
+	DriftMissingDevice,
+	DriftUnexpectedDevice,
+	DriftZoneMismatch,
+	DriftPortMismatch,
+	DriftWeight,
+	DriftMeta,
+	DriftOverload,
+	DriftSetting,
+}
+
+// Drift is a single difference between the rules and a ring.
+// Zone, NodeIP and Device are empty for differences of the whole ring.
+type Drift struct {
+	Category DriftCategory
+	Zone     uint64
+	NodeIP   string
+	Device   string
+	Message  string
+}
+
+// String returns the drift like "z1 10.114.1.202/swift-01: weight is 100 but should be 166 (-39.8%)"
+func (d Drift) String() string {
+	if d.NodeIP == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("z%d %s/%s: %s", d.Zone, d.NodeIP, d.Device, d.Message)
+}
+
+// CountDrift returns how many differences there are per category
+func CountDrift(drift []Drift) map[DriftCategory]int {
+	counts := make(map[DriftCategory]int)
+	for _, d := range drift {
+		counts[d.Category]++
+	}
+	return counts
+}
+
+// weightDriftMessage describes a weight difference with its deviation in percent from the desired weight
+func weightDriftMessage(actual, desired float64) string {
+	if desired == 0 {
+		return fmt.Sprintf("weight is %g but should be 0", actual)
+	}
+	return fmt.Sprintf("weight is %g but should be %g (%+.1f%%)", actual, desired, (actual-desired)/desired*100)
+}
+
+// Drift compares the ring with the rules and returns all differences.
+// Unlike CalculateChanges, zone and port mismatches are reported as drift instead of failing.
+func (ringRules RingRules) Drift(ring builderfile.RingInfo) ([]Drift, error) {
+	if len(ring.Devices) > 0 && (ringRules.Region != ring.Regions || ring.Regions != 1) {
+		return nil, errors.New("currently only one region is supported")
+	}
+
+	var drift []Drift
+	if diff := math.Abs(ring.OverloadFactorDecimal - ringRules.Overload); diff > 0.000001 {
+		drift = append(drift, Drift{
+			Category: DriftOverload,
+			Message:  fmt.Sprintf("overload is %g but should be %g", ring.OverloadFactorDecimal, ringRules.Overload),
+		})
+	}
+	if ringRules.Replicas != 0 && math.Abs(ringRules.Replicas-ring.Replicas) > 0.000001 {
+		drift = append(drift, Drift{
+			Category: DriftSetting,
+			Message:  fmt.Sprintf("replicas is %g but should be %g", ring.Replicas, ringRules.Replicas),
+		})
+	}
+	if ringRules.MinPartHours != nil && *ringRules.MinPartHours != ring.ReassignedCooldown {
+		drift = append(drift, Drift{
+			Category: DriftSetting,
+			Message:  fmt.Sprintf("min_part_hours is %d but should be %d", ring.ReassignedCooldown, *ringRules.MinPartHours),
+		})
+	}
+	if ringRules.PartPower != 0 && ringRules.PartPower != ring.CurrentPartPower() {
+		drift = append(drift, Drift{
+			Category: DriftSetting,
+			Message:  fmt.Sprintf("part_power is %d but should be %d", ring.CurrentPartPower(), ringRules.PartPower),
+		})
+	}
+
+	type deviceKey struct {
+		NodeIP string
+		Name   string
+	}
+	matched := make(map[deviceKey]bool)
+
+	for _, zone := range ringRules.getZones() {
+		nodes := ringRules.Zones[zone].nodes()
+		for _, nodeIP := range sortedNodeIPs(nodes) {
+			nodeRules := nodes[nodeIP]
+			port := ringRules.nodePort(*nodeRules)
+
+			for diskNumber := uint64(1); diskNumber <= nodeRules.DiskCount; diskNumber++ {
+				diskName := DiskName(diskNumber)
+				if slices.Contains(nodeRules.BrokenDisks, diskName) {
+					continue
+				}
+
+				weight, _, err := ringRules.DiskWeight(*nodeRules, nodeIP, diskName)
+				if err != nil {
+					return nil, err
+				}
+
+				idx := slices.IndexFunc(ring.Devices, func(device builderfile.DeviceInfo) bool {
+					return device.NodeIP == nodeIP && device.Name == diskName
+				})
+				if idx == -1 {
+					drift = append(drift, Drift{
+						Category: DriftMissingDevice,
+						Zone:     zone,
+						NodeIP:   nodeIP,
+						Device:   diskName,
+						Message:  fmt.Sprintf("device is missing from the ring, expected with port %d and weight %g", port, weight),
+					})
+					continue
+				}
+				device := ring.Devices[idx]
+				matched[deviceKey{device.NodeIP, device.Name}] = true
+
+				newDrift := func(category DriftCategory, message string) Drift {
+					return Drift{Category: category, Zone: device.Zone, NodeIP: nodeIP, Device: diskName, Message: message}
+				}
+				if device.Zone != zone {
+					drift = append(drift, newDrift(DriftZoneMismatch, fmt.Sprintf("device is in zone %d but should be in zone %d", device.Zone, zone)))
+				}
+				if device.Port != port {
+					drift = append(drift, newDrift(DriftPortMismatch, fmt.Sprintf("device uses port %d but should use port %d", device.Port, port)))
+				}
+				if device.Weight != weight {
+					drift = append(drift, newDrift(DriftWeight, weightDriftMessage(device.Weight, weight)))
+				}
+				if nodeRules.Meta != nil && !reflect.DeepEqual(device.Meta, nodeRules.Meta) {
+					var meta map[string]string
+					if device.Meta != nil {
+						meta = *device.Meta
+					}
+					drift = append(drift, newDrift(DriftMeta, fmt.Sprintf("meta is %v but should be %v", meta, *nodeRules.Meta)))
+				}
+			}
+		}
+	}
+
+	for _, device := range ring.Devices {
+		if matched[deviceKey{device.NodeIP, device.Name}] {
+			continue
+		}
+
+		reason := "node is not in the rules"
+		if _, nodeRules, ok := ringRules.FindNode(device.NodeIP); ok {
+			reason = fmt.Sprintf("device is not within the disk_count of %d of the node", nodeRules.DiskCount)
+			if slices.Contains(nodeRules.BrokenDisks, device.Name) {
+				reason = "device is listed in broken_disks"
+			}
+		}
+		drift = append(drift, Drift{
+			Category: DriftUnexpectedDevice,
+			Zone:     device.Zone,
+			NodeIP:   device.NodeIP,
+			Device:   device.Name,
+			Message:  fmt.Sprintf("%s, weight is %g", reason, device.Weight),
+		})
+	}
+
+	return drift, nil
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestDriftNone(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &ring)
+
+	drift, err := ring.Drift(input)
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "drift", drift, []Drift(nil))
+}
+
+func TestDrift(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-drift.yaml", &ring)
+
+	drift, err := ring.Drift(input)
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "drift", drift, []Drift{
+		{Category: DriftOverload, Message: "overload is 0 but should be 0.1"},
+		{Category: DriftSetting, Message: "min_part_hours is 24 but should be 1"},
+		{Category: DriftPortMismatch, Zone: 1, NodeIP: "10.114.1.202", Device: "swift-01", Message: "device uses port 6001 but should use port 6002"},
+		{Category: DriftMeta, Zone: 1, NodeIP: "10.114.1.202", Device: "swift-01", Message: "meta is map[] but should be map[host:swift-node-a]"},
+		{Category: DriftPortMismatch, Zone: 1, NodeIP: "10.114.1.202", Device: "swift-02", Message: "device uses port 6001 but should use port 6002"},
+		{Category: DriftMeta, Zone: 1, NodeIP: "10.114.1.202", Device: "swift-02", Message: "meta is map[] but should be map[host:swift-node-a]"},
+		{Category: DriftZoneMismatch, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-01", Message: "device is in zone 1 but should be in zone 2"},
+		{Category: DriftWeight, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-01", Message: "weight is 100 but should be 166 (-39.8%)"},
+		{Category: DriftZoneMismatch, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-02", Message: "device is in zone 1 but should be in zone 2"},
+		{Category: DriftWeight, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-02", Message: "weight is 100 but should be 166 (-39.8%)"},
+		{Category: DriftZoneMismatch, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-03", Message: "device is in zone 1 but should be in zone 2"},
+		{Category: DriftWeight, Zone: 1, NodeIP: "10.114.1.203", Device: "swift-03", Message: "weight is 100 but should be 166 (-39.8%)"},
+		{Category: DriftMissingDevice, Zone: 2, NodeIP: "10.114.1.203", Device: "swift-04", Message: "device is missing from the ring, expected with port 6001 and weight 166"},
+		{Category: DriftUnexpectedDevice, Zone: 1, NodeIP: "10.114.1.202", Device: "swift-03", Message: "device is not within the disk_count of 2 of the node, weight is 100"},
+	})
+
+	assert.DeepEqual(t, "counts", CountDrift(drift), map[DriftCategory]int{
+		DriftMissingDevice:    1,
+		DriftUnexpectedDevice: 1,
+		DriftZoneMismatch:     3,
+		DriftPortMismatch:     2,
+		DriftWeight:           3,
+		DriftMeta:             2,
+		DriftOverload:         1,
+		DriftSetting:          1,
+	})
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// readFiles reads a rule file or all *.yaml and *.yml files of a directory together with the files they include.
+// All fragments are merged into one rule file.
+// Every file is rendered as template with the given values first.
+func readFiles(path string, values map[string]any) (File, error) {
+	merger := fileMerger{
+		values:  values,
+		result:  File{Profiles: make(map[string]NodeRules), Rings: make(map[string]RingRules)},
+		origins: make(map[string]string),
+		visited: make(map[string]bool),
+	}
+	if err := merger.readPath(path); err != nil {
+		return File{}, err
+	}
+	if len(merger.result.Profiles) == 0 {
+		merger.result.Profiles = nil
+	}
+	return merger.result, nil
+}
+
+// fileMerger remembers in which file each part of the rules was defined to report conflicts
+type fileMerger struct {
+	values  map[string]any
+	result  File
+	origins map[string]string
+	visited map[string]bool
+}
+
+// readPath reads a file, a directory or all files matching a glob pattern
+func (m *fileMerger) readPath(path string) error {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		var filenames []string
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return err
+			}
+			filenames = append(filenames, matches...)
+		}
+		if len(filenames) == 0 {
+			return fmt.Errorf("directory %s does not contain any rule files", path)
+		}
+		slices.Sort(filenames)
+		for _, filename := range filenames {
+			if err := m.readFile(filename); err != nil {
+				return err
+			}
+		}
+		return nil
+	case err == nil:
+		return m.readFile(path)
+	case errors.Is(err, os.ErrNotExist) && strings.ContainsAny(path, "*?["):
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("%s does not match any rule files", path)
+		}
+		for _, match := range matches {
+			if err := m.readPath(match); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return err
+	}
+}
+
+func (m *fileMerger) readFile(filename string) error {
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if m.visited[absFilename] {
+		return fmt.Errorf("%s is included multiple times", filename)
+	}
+	m.visited[absFilename] = true
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	// other rule files may contain "{{" e.g. in meta values and are only rendered if values are given
+	if m.values != nil || IsTemplate(data) {
+		data, err = renderTemplate(filename, data, m.values)
+		if err != nil {
+			return fmt.Errorf("rendering %s failed: %w", filename, err)
+		}
+	}
+	var file File
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return fmt.Errorf("parsing %s failed: %w", filename, err)
+	}
+
+	if err := m.merge(file, filename); err != nil {
+		return err
+	}
+
+	// includes are relative to the including file
+	for _, include := range file.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filename), include)
+		}
+		if err := m.readPath(include); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+// claim records that something is defined in filename and fails if it was already defined in another file
+func (m *fileMerger) claim(what, filename string) error {
+	if other, ok := m.origins[what]; ok {
+		return fmt.Errorf("%s is defined in both %s and %s", what, other, filename)
+	}
+	m.origins[what] = filename
+	return nil
+}
+
+func (m *fileMerger) merge(file File, filename string) error {
+	for _, name := range slices.Sorted(maps.Keys(file.Profiles)) {
+		if err := m.claim(fmt.Sprintf("profile %q", name), filename); err != nil {
+			return err
+		}
+		m.result.Profiles[name] = file.Profiles[name]
+	}
+
+	for _, ringName := range GetRingNames(file.Rings) {
+		ringRules := file.Rings[ringName]
+		merged, ok := m.result.Rings[ringName]
+		if !ok {
+			merged = RingRules{Zones: make(map[uint64]*ZoneRules)}
+		}
+
+		if err := m.mergeRingSettings(&merged, ringRules, ringName, filename); err != nil {
+			return err
+		}
+
+		for _, zone := range ringRules.getZones() {
+			zoneRules := ringRules.Zones[zone]
+			mergedZone, ok := merged.Zones[zone]
+			if !ok {
+				mergedZone = &ZoneRules{Nodes: make(map[string]*NodeRules)}
+				merged.Zones[zone] = mergedZone
+			}
+			if zoneRules == nil {
+				continue
+			}
+			if zoneRules.Defaults != nil {
+				if err := m.claim(fmt.Sprintf("defaults of %s zone %d", ringName, zone), filename); err != nil {
+					return err
+				}
+				mergedZone.Defaults = zoneRules.Defaults
+			}
+			// node ranges are merged as they are, so that their exceptions are kept
+			for _, nodeIP := range sortedNodeIPs(zoneRules.Nodes) {
+				if err := m.claim(fmt.Sprintf("node %s of %s", nodeIP, ringName), filename); err != nil {
+					return err
+				}
+				mergedZone.Nodes[nodeIP] = zoneRules.Nodes[nodeIP]
+			}
+		}
+		m.result.Rings[ringName] = merged
+	}
+	return nil
+}
+
+// mergeRingSettings copies all settings of a ring except the zones.
+// Settings may be repeated in multiple files as long as they have the same value.
+func (m *fileMerger) mergeRingSettings(merged *RingRules, ringRules RingRules, ringName, filename string) error {
+	target := reflect.ValueOf(merged).Elem()
+	source := reflect.ValueOf(ringRules)
+	for i := range source.NumField() {
+		field := source.Type().Field(i)
+		value := source.Field(i)
+		if field.Name == "Zones" || value.IsZero() {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		what := fmt.Sprintf("%s of %s", key, ringName)
+		if existing := target.Field(i); !existing.IsZero() && !reflect.DeepEqual(existing.Interface(), value.Interface()) {
+			return fmt.Errorf("%s is defined differently in %s and %s", what, m.origins[what], filename)
+		}
+		if _, ok := m.origins[what]; !ok {
+			m.origins[what] = filename
+		}
+		target.Field(i).Set(value)
+	}
+	return nil
+}
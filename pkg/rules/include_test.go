@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestInclude(t *testing.T) {
+	file := must.Return(readFiles("../../testing/artisan-include-main.yaml", nil))
+	rings := must.Return(file.Resolve())
+
+	node := NodeRules{DiskCount: 3, DiskSize: 6 * misc.Terabyte}
+	nodeWithPort := node
+	nodeWithPort.Port = 6002
+	assert.DeepEqual(t, "merged rules", rings["object.builder"], RingRules{
+		BaseSize: 6 * misc.Terabyte,
+		BasePort: 6001,
+		Region:   1,
+		Zones: map[uint64]*ZoneRules{
+			1: {Nodes: map[string]*NodeRules{"10.114.1.202": &node}},
+			2: {Nodes: map[string]*NodeRules{"10.114.1.204": &node}},
+			3: {Nodes: map[string]*NodeRules{"10.114.1.206": &nodeWithPort}},
+		},
+	})
+}
+
+func writeRuleFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for filename, content := range files {
+		must.SucceedT(t, os.WriteFile(filepath.Join(dir, filename), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestIncludeDirectory(t *testing.T) {
+	dir := writeRuleFiles(t, map[string]string{
+		"account.yaml":   "account.builder:\n  region: 1\n  zones:\n    1:\n      nodes:\n        10.114.1.202:\n          disk_count: 1\n",
+		"container.yml":  "container.builder:\n  region: 1\n  zones:\n    1:\n      nodes:\n        10.114.1.202:\n          disk_count: 2\n",
+		"ignored.txt":    "not a rule file",
+		"object-z1.yaml": "object.builder:\n  zones:\n    1:\n      nodes:\n        10.114.1.202:\n          disk_count: 3\n",
+	})
+
+	file := must.Return(readFiles(dir, nil))
+	assert.DeepEqual(t, "ring names", GetRingNames(file.Rings), []string{"account.builder", "container.builder", "object.builder"})
+}
+
+func TestIncludeConflicts(t *testing.T) {
+	testCases := []struct {
+		Files    map[string]string
+		Path     string
+		Expected string
+	}{
+		{
+			Files: map[string]string{
+				"a.yaml": "object.builder:\n  zones:\n    1:\n      nodes:\n        10.114.1.202:\n          disk_count: 1\n",
+				"b.yaml": "object.builder:\n  zones:\n    1:\n      nodes:\n        10.114.1.202:\n          disk_count: 2\n",
+			},
+			Expected: "node 10.114.1.202 of object.builder is defined in both DIR/a.yaml and DIR/b.yaml",
+		},
+		{
+			Files: map[string]string{
+				"a.yaml": "object.builder:\n  base_port: 6001\n",
+				"b.yaml": "object.builder:\n  base_port: 6002\n",
+			},
+			Expected: "base_port of object.builder is defined differently in DIR/a.yaml and DIR/b.yaml",
+		},
+		{
+			Files: map[string]string{
+				"a.yaml": "profiles:\n  hdd:\n    disk_count: 1\n",
+				"b.yaml": "profiles:\n  hdd:\n    disk_count: 2\n",
+			},
+			Expected: `profile "hdd" is defined in both DIR/a.yaml and DIR/b.yaml`,
+		},
+		{
+			Files: map[string]string{
+				"a.yaml": "include: [b.yaml]\n",
+				"b.yaml": "include: [a.yaml]\n",
+			},
+			Path:     "a.yaml",
+			Expected: "DIR/a.yaml: DIR/b.yaml: DIR/a.yaml is included multiple times",
+		},
+		{
+			Files:    map[string]string{"a.yaml": "include: [missing-*.yaml]\n"},
+			Path:     "a.yaml",
+			Expected: "DIR/a.yaml: DIR/missing-*.yaml does not match any rule files",
+		},
+	}
+
+	for _, testCase := range testCases {
+		dir := writeRuleFiles(t, testCase.Files)
+		_, err := readFiles(filepath.Join(dir, testCase.Path), nil)
+		assert.ErrEqual(t, err, strings.ReplaceAll(testCase.Expected, "DIR", dir))
+	}
+}
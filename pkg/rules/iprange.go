@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxNodeRangeSize limits how many nodes a single key in ZoneRules.Nodes can expand to
+const maxNodeRangeSize = 4096
+
+// expandNodeKey returns the IPs which a key of ZoneRules.Nodes refers to.
+// Besides single IPs the following patterns are supported:
+//
+//	10.46.14.0/29                     CIDR block, for IPv4 without the network and broadcast address
+//	10.46.14.204-10.46.14.220         every IP from the first to the last one
+//	10.46.14.204-10.46.14.220 step 8  every 8th IP from the first to the last one
+//
+// Keys which do not look like one of the patterns are returned unchanged, isRange is false for them.
+func expandNodeKey(key string) (nodeIPs []string, isRange bool, err error) {
+	rangeStr, stepStr, hasStep := strings.Cut(key, " step ")
+	step := uint64(1)
+	if hasStep {
+		step, err = strconv.ParseUint(strings.TrimSpace(stepStr), 10, 64)
+		if err != nil || step == 0 {
+			return nil, true, fmt.Errorf("node range %q has an invalid step %q", key, stepStr)
+		}
+	}
+	rangeStr = strings.TrimSpace(rangeStr)
+
+	var first, last netip.Addr
+	switch {
+	case strings.Contains(rangeStr, "/"):
+		prefix, err := netip.ParsePrefix(rangeStr)
+		if err != nil {
+			return nil, true, fmt.Errorf("node range %q is not a valid CIDR block: %w", key, err)
+		}
+		if prefix != prefix.Masked() {
+			return nil, true, fmt.Errorf("node range %q is not a valid CIDR block: host bits are set", key)
+		}
+		first = prefix.Addr()
+		last = lastAddr(prefix)
+		// network and broadcast address cannot be used by nodes
+		if first.Is4() && prefix.Bits() < 31 {
+			first, last = first.Next(), last.Prev()
+		}
+	default:
+		firstStr, lastStr, ok := strings.Cut(rangeStr, "-")
+		if !ok {
+			if hasStep {
+				return nil, true, fmt.Errorf("node range %q needs a first and last IP to use a step", key)
+			}
+			return []string{key}, false, nil
+		}
+		var errFirst, errLast error
+		first, errFirst = netip.ParseAddr(strings.TrimSpace(firstStr))
+		last, errLast = netip.ParseAddr(strings.TrimSpace(lastStr))
+		if errFirst != nil || errLast != nil {
+			// hostnames may contain dashes
+			if hasStep {
+				return nil, true, fmt.Errorf("node range %q needs a first and last IP to use a step", key)
+			}
+			return []string{key}, false, nil
+		}
+		if first.BitLen() != last.BitLen() || last.Less(first) {
+			return nil, true, fmt.Errorf("node range %q does not end after it starts", key)
+		}
+	}
+
+	// calculate arithmetically to not iterate over every IP of huge ranges or steps
+	size := new(big.Int).Sub(addrToInt(last), addrToInt(first))
+	stepInt := new(big.Int).SetUint64(step)
+	if stepInt.Cmp(size) > 0 && size.Sign() > 0 {
+		return nil, true, fmt.Errorf("node range %q has a step %d which is larger than the distance of %s between the first and last IP", key, step, size)
+	}
+	count := new(big.Int).Div(size, stepInt)
+	if count.Cmp(big.NewInt(maxNodeRangeSize-1)) > 0 {
+		return nil, true, fmt.Errorf("node range %q covers more than %d IPs", key, maxNodeRangeSize)
+	}
+
+	for i := int64(0); i <= count.Int64(); i++ {
+		offset := new(big.Int).Mul(stepInt, big.NewInt(i))
+		nodeIPs = append(nodeIPs, intToAddr(new(big.Int).Add(addrToInt(first), offset), first.BitLen()).String())
+	}
+	return nodeIPs, true, nil
+}
+
+func addrToInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+// intToAddr converts an address calculated by addrToInt back, the value needs to fit into the address length
+func intToAddr(value *big.Int, bitLen int) netip.Addr {
+	addr, _ := netip.AddrFromSlice(value.FillBytes(make([]byte, bitLen/8)))
+	return addr
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(addr)*8; bit++ {
+		addr[bit/8] |= 1 << (7 - bit%8)
+	}
+	last, _ := netip.AddrFromSlice(addr)
+	return last
+}
+
+// ExpandNodes returns the rules of every node IP of the zone.
+// Nodes which are covered by a range share the rules of the range.
+// A single IP which is also covered by a range is an exception whose values override the values of the range.
+func (zoneRules ZoneRules) ExpandNodes() (map[string]*NodeRules, error) {
+	expanded := make(map[string]*NodeRules, len(zoneRules.Nodes))
+	rangeOf := make(map[string]string)
+
+	var exceptions []string
+	for _, key := range sortedNodeIPs(zoneRules.Nodes) {
+		nodeIPs, isRange, err := expandNodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if !isRange {
+			exceptions = append(exceptions, key)
+			continue
+		}
+		for _, nodeIP := range nodeIPs {
+			if other, ok := rangeOf[nodeIP]; ok {
+				return nil, fmt.Errorf("node %s is covered by the node ranges %q and %q", nodeIP, other, key)
+			}
+			rangeOf[nodeIP] = key
+			expanded[nodeIP] = zoneRules.Nodes[key]
+		}
+	}
+
+	for _, nodeIP := range exceptions {
+		own := zoneRules.Nodes[nodeIP]
+		switch rangeRules, covered := expanded[nodeIP]; {
+		case !covered || rangeRules == nil:
+			expanded[nodeIP] = own
+		case own != nil:
+			merged := rangeRules.overrideWith(*own)
+			expanded[nodeIP] = &merged
+		}
+	}
+	return expanded, nil
+}
+
+// nodes is like ExpandNodes, but keys which cannot be expanded are kept as they are.
+// Invalid keys are reported when the rules are resolved.
+func (zoneRules ZoneRules) nodes() map[string]*NodeRules {
+	expanded, err := zoneRules.ExpandNodes()
+	if err != nil {
+		return zoneRules.Nodes
+	}
+	return expanded
+}
+
+// sortedNodeIPs returns the IPs of the nodes in a stable order
+func sortedNodeIPs(nodes map[string]*NodeRules) []string {
+	nodeIPs := make([]string, 0, len(nodes))
+	for nodeIP := range nodes {
+		nodeIPs = append(nodeIPs, nodeIP)
+	}
+	slices.Sort(nodeIPs) // for reproducibility in tests
+	return nodeIPs
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestExpandNodeKey(t *testing.T) {
+	testCases := map[string][]string{
+		"10.114.1.202":                     {"10.114.1.202"},
+		"node-01.example.com":              {"node-01.example.com"},
+		"10.46.14.0/29":                    {"10.46.14.1", "10.46.14.2", "10.46.14.3", "10.46.14.4", "10.46.14.5", "10.46.14.6"},
+		"10.46.14.8/31":                    {"10.46.14.8", "10.46.14.9"},
+		"10.46.14.254-10.46.15.1":          {"10.46.14.254", "10.46.14.255", "10.46.15.0", "10.46.15.1"},
+		"10.46.14.204-10.46.14.220 step 8": {"10.46.14.204", "10.46.14.212", "10.46.14.220"},
+		"10.46.14.204-10.46.14.225 step 8": {"10.46.14.204", "10.46.14.212", "10.46.14.220"},
+		"fd00::1-fd00::3":                  {"fd00::1", "fd00::2", "fd00::3"},
+	}
+	for key, expected := range testCases {
+		nodeIPs, _, err := expandNodeKey(key)
+		assert.ErrEqual(t, err, nil)
+		assert.DeepEqual(t, key, nodeIPs, expected)
+	}
+
+	errorCases := map[string]string{
+		"10.46.14.1/29":                `node range "10.46.14.1/29" is not a valid CIDR block: host bits are set`,
+		"10.46.14.220-10.46.14.204":    `node range "10.46.14.220-10.46.14.204" does not end after it starts`,
+		"10.46.14.204-fd00::1":         `node range "10.46.14.204-fd00::1" does not end after it starts`,
+		"10.46.14.204 step 8":          `node range "10.46.14.204 step 8" needs a first and last IP to use a step`,
+		"10.46.14.1-10.46.14.9 step 0": `node range "10.46.14.1-10.46.14.9 step 0" has an invalid step "0"`,
+		"10.0.0.0/8":                   `node range "10.0.0.0/8" covers more than 4096 IPs`,
+		// a typo in the step must not make loading the rules hang
+		"10.46.14.1-10.46.14.9 step 1000000000000":                             `node range "10.46.14.1-10.46.14.9 step 1000000000000" has a step 1000000000000 which is larger than the distance of 8 between the first and last IP`,
+		"::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff step 18446744073709551615": `node range "::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff step 18446744073709551615" covers more than 4096 IPs`,
+	}
+	for key, expected := range errorCases {
+		_, _, err := expandNodeKey(key)
+		assert.ErrEqual(t, err, expected)
+	}
+}
+
+func TestExpandNodesWithExceptions(t *testing.T) {
+	weight := 0.0
+	zoneRules := ZoneRules{Nodes: map[string]*NodeRules{
+		"10.46.14.204-10.46.14.220 step 8": {DiskCount: 12, DiskSize: 6 * misc.Terabyte},
+		"10.46.14.212":                     {Weight: &weight},
+		"10.46.14.230":                     {DiskCount: 40},
+	}}
+
+	nodes, err := zoneRules.ExpandNodes()
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "node IPs", sortedNodeIPs(nodes), []string{"10.46.14.204", "10.46.14.212", "10.46.14.220", "10.46.14.230"})
+	assert.DeepEqual(t, "range", *nodes["10.46.14.204"], NodeRules{DiskCount: 12, DiskSize: 6 * misc.Terabyte})
+	assert.DeepEqual(t, "exception", *nodes["10.46.14.212"], NodeRules{DiskCount: 12, DiskSize: 6 * misc.Terabyte, Weight: &weight})
+	assert.DeepEqual(t, "single node", *nodes["10.46.14.230"], NodeRules{DiskCount: 40})
+
+	zoneRules.Nodes["10.46.14.192/27"] = &NodeRules{DiskCount: 1}
+	_, err = zoneRules.ExpandNodes()
+	assert.ErrEqual(t, err, `node 10.46.14.204 is covered by the node ranges "10.46.14.192/27" and "10.46.14.204-10.46.14.220 step 8"`)
+}
+
+func TestApplyRulesWithRanges(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-ranges.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "commands", commandQueue, []string(nil))
+	assert.DeepEqual(t, "confirmations", confirmations, []string(nil))
+
+	// changing one node of the range adds an exception for it
+	nodeRules, ok := ring.EditNode("10.114.1.203")
+	assert.Equal(t, ok, true)
+	nodeRules.BrokenDisks = []string{"swift-02"}
+	commandQueue, _, err = ring.CalculateChanges(input, "/dev/null")
+	assert.ErrEqual(t, err, nil)
+	assert.DeepEqual(t, "commands", commandQueue, []string{
+		"swift-ring-builder /dev/null remove --region 1 --zone 1 --ip 10.114.1.203 --port 6001 --device swift-02 --weight 100",
+	})
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"slices"
+)
+
+// GetRingNames returns the builder file names of all rings in a rule file in a stable order.
+func GetRingNames(file map[string]RingRules) []string {
+	var ringNames []string
+	for ringName := range file {
+		ringNames = append(ringNames, ringName)
+	}
+	slices.Sort(ringNames)
+
+	return ringNames
+}
+
+type sharedNodeLocation struct {
+	RingName string
+	Region   uint64
+	Zone     uint64
+	NodeIP   string
+}
+
+// CheckSharedNodes verifies that nodes which are part of multiple rings are defined consistently.
+// A node IP must be located in the same region and zone in every ring
+// and a hostname in the meta data must always refer to the same node IP.
+func CheckSharedNodes(file map[string]RingRules) []error {
+	var errs []error
+	nodesByIP := make(map[string]sharedNodeLocation)
+	nodesByHostname := make(map[string]sharedNodeLocation)
+
+	for _, ringName := range GetRingNames(file) {
+		ringRules := file[ringName]
+		for _, zone := range ringRules.getZones() {
+			zoneRules := ringRules.Zones[zone]
+			nodes := zoneRules.nodes()
+			for _, nodeIP := range sortedNodeIPs(nodes) {
+				location := sharedNodeLocation{RingName: ringName, Region: ringRules.Region, Zone: zone, NodeIP: nodeIP}
+
+				if other, ok := nodesByIP[nodeIP]; !ok {
+					nodesByIP[nodeIP] = location
+				} else if other.Region != location.Region || other.Zone != location.Zone {
+					errs = append(errs, fmt.Errorf("node %s is in region %d zone %d in %s but in region %d zone %d in %s",
+						nodeIP, other.Region, other.Zone, other.RingName, location.Region, location.Zone, location.RingName))
+				}
+
+				nodeRules := nodes[nodeIP]
+				if nodeRules == nil || nodeRules.Meta == nil {
+					continue
+				}
+				hostname, ok := (*nodeRules.Meta)["hostname"]
+				if !ok {
+					continue
+				}
+				if other, ok := nodesByHostname[hostname]; !ok {
+					nodesByHostname[hostname] = location
+				} else if other.NodeIP != location.NodeIP {
+					errs = append(errs, fmt.Errorf("hostname %s has IP %s in %s but IP %s in %s",
+						hostname, other.NodeIP, other.RingName, location.NodeIP, location.RingName))
+				}
+			}
+		}
+	}
+
+	return errs
+}
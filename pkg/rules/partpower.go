@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+)
+
+// PartPowerPhase is a step of swift's partition power increase workflow.
+// The phase is not stored by swift-ring-artisan but derived from part_power and next_part_power in the builder file,
+// which allows to resume the workflow with every apply run until the target partition power is reached.
+type PartPowerPhase string
+
+const (
+	// PartPowerPhaseNone means that no partition power increase is required
+	PartPowerPhaseNone PartPowerPhase = ""
+	// PartPowerPhasePrepare means that prepare_increase_partition_power needs to run
+	PartPowerPhasePrepare PartPowerPhase = "prepare"
+	// PartPowerPhaseIncrease means that the ring was prepared and increase_partition_power needs to run after relinking
+	PartPowerPhaseIncrease PartPowerPhase = "increase"
+	// PartPowerPhaseFinish means that the partition power was increased and finish_increase_partition_power needs to run after the cleanup
+	PartPowerPhaseFinish PartPowerPhase = "finish"
+)
+
+// PartPowerPlan describes the progress of a partition power increase
+type PartPowerPlan struct {
+	Phase            PartPowerPhase
+	CurrentPartPower uint64
+	TargetPartPower  uint64
+	// Command is the swift-ring-builder command that advances the workflow to the next phase
+	Command string
+	// Confirmation needs to be acknowledged by the operator before the Command is run
+	Confirmation string
+}
+
+// PartPowerPlan determines which phase of the partition power increase workflow the ring is in
+func (ringRules RingRules) PartPowerPlan(ring builderfile.RingInfo, ringFilename string) (PartPowerPlan, error) {
+	partPower := ring.CurrentPartPower()
+	plan := PartPowerPlan{
+		CurrentPartPower: partPower,
+		TargetPartPower:  ringRules.PartPower,
+	}
+
+	if ring.Partitions != 0 && ring.Partitions != 1<<partPower {
+		return plan, fmt.Errorf("ring has %d partitions which does not match its partition power %d", ring.Partitions, partPower)
+	}
+
+	switch ring.NextPartPower {
+	case 0:
+		if ringRules.PartPower == 0 || ringRules.PartPower == partPower {
+			return plan, nil
+		}
+		if ringRules.PartPower < partPower {
+			return plan, fmt.Errorf("partition power cannot be decreased from %d to %d", partPower, ringRules.PartPower)
+		}
+		plan.Phase = PartPowerPhasePrepare
+		plan.Command = ring.CommandPrepareIncreasePartitionPower(ringFilename)
+		plan.Confirmation = fmt.Sprintf("Do you want to start increasing the partition power from %d to %d? Make sure that all object servers are configured to handle a partition power increase. Afterwards the new ring needs to be distributed and swift-object-relinker relink needs to run on all storage nodes.",
+			partPower, partPower+1)
+	case partPower + 1:
+		plan.TargetPartPower = max(ringRules.PartPower, ring.NextPartPower)
+		plan.Phase = PartPowerPhaseIncrease
+		plan.Command = ring.CommandIncreasePartitionPower(ringFilename)
+		plan.Confirmation = fmt.Sprintf("Did swift-object-relinker relink finish on all storage nodes for the partition power increase from %d to %d? Afterwards the new ring needs to be distributed and swift-object-relinker cleanup needs to run on all storage nodes.",
+			partPower, partPower+1)
+	case partPower:
+		plan.TargetPartPower = max(ringRules.PartPower, ring.NextPartPower)
+		plan.Phase = PartPowerPhaseFinish
+		plan.Command = ring.CommandFinishIncreasePartitionPower(ringFilename)
+		plan.Confirmation = fmt.Sprintf("Did swift-object-relinker cleanup finish on all storage nodes for the partition power increase to %d?", partPower)
+	default:
+		return plan, fmt.Errorf("next partition power %d does not fit to the current partition power %d", ring.NextPartPower, partPower)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestPartPowerIncrease(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-part-power.yaml", &ring)
+
+	// phase 1: prepare
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "parsing", commandQueue, []string{"swift-ring-builder /dev/null prepare_increase_partition_power"})
+	assert.DeepEqual(t, "parsing", len(confirmations), 1)
+
+	// phase 2: increase after relinking, other changes are postponed
+	input.NextPartPower = 11
+	input.OverloadFactorDecimal = 0.5
+	plan, err := ring.PartPowerPlan(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "plan", plan, PartPowerPlan{
+		Phase:            PartPowerPhaseIncrease,
+		CurrentPartPower: 10,
+		TargetPartPower:  11,
+		Command:          "swift-ring-builder /dev/null increase_partition_power",
+		Confirmation:     "Did swift-object-relinker relink finish on all storage nodes for the partition power increase from 10 to 11? Afterwards the new ring needs to be distributed and swift-object-relinker cleanup needs to run on all storage nodes.",
+	})
+	commandQueue, _, err = ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "parsing", commandQueue, []string{"swift-ring-builder /dev/null increase_partition_power"})
+
+	// phase 3: finish after cleanup
+	input.PartPower = 11
+	input.Partitions = 2048
+	commandQueue, _, err = ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "parsing", commandQueue, []string{"swift-ring-builder /dev/null finish_increase_partition_power"})
+
+	// done: only the postponed changes remain
+	input.NextPartPower = 0
+	commandQueue, _, err = ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "parsing", commandQueue, []string{"swift-ring-builder /dev/null set_overload 0.000000"})
+}
+
+func TestPartPowerErrors(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-part-power.yaml", &ring)
+
+	ring.PartPower = 9
+	_, _, err := ring.CalculateChanges(input, "/dev/null")
+	assert.ErrEqual(t, err, "partition power cannot be decreased from 10 to 9")
+
+	input.PartPower = 11
+	_, _, err = ring.CalculateChanges(input, "/dev/null")
+	assert.ErrEqual(t, err, "ring has 1024 partitions which does not match its partition power 11")
+
+	input.PartPower = 10
+	input.NextPartPower = 12
+	_, _, err = ring.CalculateChanges(input, "/dev/null")
+	assert.ErrEqual(t, err, "next partition power 12 does not fit to the current partition power 10")
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/sapcc/go-bits/logg"
+)
+
+// File is the content of a rule file.
+// Besides the rings, which are keyed by their builder file name, it can define named node profiles
+// which nodes, zone defaults and ring defaults refer to with profile.
+// Include lists further rule files, directories or glob patterns relative to the file which are merged into it.
+type File struct {
+	Include  []string             `yaml:"include,omitempty"`
+	Profiles map[string]NodeRules `yaml:"profiles,omitempty"`
+	Rings    map[string]RingRules `yaml:",inline"`
+}
+
+// ReadFile reads a rule file or a directory of rule files together with all included files
+// without resolving profiles and defaults. Files are rendered as templates first if values are given or they start with the TemplateMarker.
+func ReadFile(path string, values map[string]any) File {
+	file, err := readFiles(path, values)
+	if err != nil {
+		logg.Fatal("Reading rules from %s failed: %s", path, err.Error())
+	}
+	return file
+}
+
+// Load reads a rule file or a directory of rule files and resolves profiles and defaults into the rules of every node
+func Load(filename string, values map[string]any) map[string]RingRules {
+	rings, err := ReadFile(filename, values).Resolve()
+	if err != nil {
+		logg.Fatal("Resolving %s failed: %s", filename, err.Error())
+	}
+	return rings
+}
+
+// Resolve cascades the ring defaults, zone defaults and profiles into the rules of every node
+func (file File) Resolve() (map[string]RingRules, error) {
+	resolved := make(map[string]RingRules, len(file.Rings))
+	for _, ringName := range GetRingNames(file.Rings) {
+		ringRules, err := file.Rings[ringName].Resolve(file.Profiles)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ringName, err)
+		}
+		resolved[ringName] = ringRules
+	}
+	return resolved, nil
+}
+
+// Resolve returns a copy of the ring rules in which node ranges are expanded and every node contains all values that apply to it.
+// Values are taken from the ring defaults, the zone defaults, the profile and the node itself,
+// where each of them overrides the values of the previous ones.
+func (ringRules RingRules) Resolve(profiles map[string]NodeRules) (RingRules, error) {
+	resolved := ringRules
+	resolved.Defaults = nil
+	resolved.Zones = make(map[uint64]*ZoneRules, len(ringRules.Zones))
+
+	for _, zone := range ringRules.getZones() {
+		zoneRules := ringRules.Zones[zone]
+		resolvedZone := &ZoneRules{Nodes: make(map[string]*NodeRules, len(zoneRules.Nodes))}
+
+		var defaults NodeRules
+		if ringRules.Defaults != nil {
+			defaults = ringRules.Defaults.overrideWith(defaults)
+		}
+		if zoneRules.Defaults != nil {
+			defaults = defaults.overrideWith(*zoneRules.Defaults)
+		}
+
+		nodes, err := zoneRules.ExpandNodes()
+		if err != nil {
+			return RingRules{}, fmt.Errorf("zone %d: %w", zone, err)
+		}
+		for _, nodeIP := range sortedNodeIPs(nodes) {
+			// a node without any values only gets the defaults
+			var own NodeRules
+			if nodes[nodeIP] != nil {
+				own = *nodes[nodeIP]
+			}
+
+			// the profile takes precedence over the defaults but not over the values of the node itself
+			base := defaults
+			if profileName := defaults.overrideWith(own).Profile; profileName != "" {
+				profile, ok := profiles[profileName]
+				if !ok {
+					return RingRules{}, fmt.Errorf("node %s refers to the unknown profile %q", nodeIP, profileName)
+				}
+				if profile.Profile != "" {
+					return RingRules{}, fmt.Errorf("profile %q cannot refer to another profile", profileName)
+				}
+				base = base.overrideWith(profile)
+			}
+			nodeRules := base.overrideWith(own)
+			nodeRules.Profile = ""
+
+			resolvedZone.Nodes[nodeIP] = &nodeRules
+		}
+		resolved.Zones[zone] = resolvedZone
+	}
+
+	if err := resolved.checkWeightUnits(); err != nil {
+		return RingRules{}, err
+	}
+	return resolved, nil
+}
+
+// overrideWith returns a copy of the node rules where all values which are set in other replace the own values.
+// Meta data is merged key by key.
+func (nodeRules NodeRules) overrideWith(other NodeRules) NodeRules {
+	result := nodeRules
+	if other.Profile != "" {
+		result.Profile = other.Profile
+	}
+	if other.Port != 0 {
+		result.Port = other.Port
+	}
+	if other.Meta != nil {
+		meta := make(map[string]string)
+		if nodeRules.Meta != nil {
+			maps.Copy(meta, *nodeRules.Meta)
+		}
+		maps.Copy(meta, *other.Meta)
+		result.Meta = &meta
+	}
+	if other.DiskCount != 0 {
+		result.DiskCount = other.DiskCount
+	}
+	if other.DiskSize != 0 {
+		result.DiskSize = other.DiskSize
+	}
+	if other.Weight != nil {
+		weight := *other.Weight
+		result.Weight = &weight
+	}
+	if other.ReportedWeight != nil {
+		reportedWeight := *other.ReportedWeight
+		result.ReportedWeight = &reportedWeight
+	}
+	if other.WeightPolicy != "" {
+		result.WeightPolicy = other.WeightPolicy
+	}
+	if other.BrokenDisks != nil {
+		result.BrokenDisks = append([]string(nil), other.BrokenDisks...)
+	}
+	if other.ZeroWeightDisks != nil {
+		result.ZeroWeightDisks = append([]string(nil), other.ZeroWeightDisks...)
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	file := ReadFile("../../testing/artisan-rules-profiles.yaml", nil)
+	assert.DeepEqual(t, "ring names", GetRingNames(file.Rings), []string{"object.builder"})
+
+	rings, err := file.Resolve()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	weight := 0.0
+	assert.DeepEqual(t, "resolved rules", rings["object.builder"], RingRules{
+		BaseSize: 6 * misc.Terabyte,
+		BasePort: 6001,
+		Region:   1,
+		Zones: map[uint64]*ZoneRules{
+			1: {Nodes: map[string]*NodeRules{
+				"10.114.1.202": {
+					Meta:      &map[string]string{"datacenter": "dc1"},
+					DiskCount: 40,
+					DiskSize:  6 * misc.Terabyte,
+				},
+				"10.114.1.203": {
+					Meta:        &map[string]string{"datacenter": "dc1"},
+					DiskCount:   40,
+					DiskSize:    6 * misc.Terabyte,
+					BrokenDisks: []string{"swift-07"},
+				},
+			}},
+			2: {Nodes: map[string]*NodeRules{
+				"10.114.1.204": {
+					Port:      6002,
+					Meta:      &map[string]string{"datacenter": "dc1", "hostname": "node204", "model": "hdd-8tb"},
+					DiskCount: 12,
+					DiskSize:  8 * misc.Terabyte,
+				},
+				"10.114.1.205": {
+					Port:      6002,
+					Meta:      &map[string]string{"datacenter": "dc1"},
+					DiskCount: 40,
+					DiskSize:  6 * misc.Terabyte,
+					Weight:    &weight,
+				},
+			}},
+		},
+	})
+}
+
+func TestResolveUnknownProfile(t *testing.T) {
+	file := File{
+		Profiles: map[string]NodeRules{"nested": {Profile: "hdd"}},
+		Rings: map[string]RingRules{"object.builder": {Zones: map[uint64]*ZoneRules{
+			1: {Nodes: map[string]*NodeRules{"10.114.1.202": {Profile: "ssd"}}},
+		}}},
+	}
+	_, err := file.Resolve()
+	assert.ErrEqual(t, err, `object.builder: node 10.114.1.202 refers to the unknown profile "ssd"`)
+
+	file.Rings["object.builder"].Zones[1].Nodes["10.114.1.202"].Profile = "nested"
+	_, err = file.Resolve()
+	assert.ErrEqual(t, err, `object.builder: profile "nested" cannot refer to another profile`)
+}
@@ -8,61 +8,95 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"regexp"
 	"slices"
-	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/sapcc/go-bits/logg"
 
 	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
 )
 
 // NodeRules is a server containing disks
 type NodeRules struct {
-	Port           uint64             `yaml:"port,omitempty"`
-	Meta           *map[string]string `yaml:"meta,omitempty"`
-	DiskCount      uint64             `yaml:"disk_count"`
-	DiskSizeTB     float64            `yaml:"disk_size_tb,omitempty"`
-	Weight         *float64           `yaml:"weight,omitempty"`
-	ReportedWeight *float64           `yaml:"reported_weight,omitempty"`
+	// Profile refers to a named profile of the rule file whose values apply unless the node overrides them.
+	Profile   string             `yaml:"profile,omitempty"`
+	Port      uint64             `yaml:"port,omitempty"`
+	Meta      *map[string]string `yaml:"meta,omitempty"`
+	DiskCount uint64             `yaml:"disk_count,omitempty"`
+	// DiskSize can be given like 7.68TB or 14TiB. The deprecated disk_size_tb is accepted for sizes as bare number of TB.
+	DiskSize       misc.ByteSize `yaml:"disk_size,omitempty"`
+	Weight         *float64      `yaml:"weight,omitempty"`
+	ReportedWeight *float64      `yaml:"reported_weight,omitempty"`
+	// WeightPolicy overrides the weight policy of the ring for this node. Policies whose weights have different units,
+	// like absolute and relative, cannot be mixed within a ring.
+	WeightPolicy string `yaml:"weight_policy,omitempty"`
 	// BrokenDisks lists device names like "swift-02" that shall be treated as non-existent.
 	BrokenDisks []string `yaml:"broken_disks,omitempty"`
+	// ZeroWeightDisks lists device names like "swift-02" which stay in the ring with weight 0,
+	// e.g. unmounted disks whose replicas shall be moved elsewhere until they are replaced.
+	ZeroWeightDisks []string `yaml:"zero_weight_disks,omitempty"`
 }
 
-func (nodeRules NodeRules) DesiredWeight(baseSizeTB float64, nodeIP string) float64 {
-	var weight float64
-	switch {
-	case nodeRules.Weight == nil && baseSizeTB == 0:
-		logg.Fatal("Applying rule %+v to disk %s:%d failed because not enough data is present to calculate the weight", nodeRules, nodeIP, nodeRules.Port)
-	case nodeRules.Weight == nil && baseSizeTB != 0:
-		if nodeRules.DiskSizeTB == 0 {
-			weight = 100
-		} else {
-			weight = math.Floor(nodeRules.DiskSizeTB / baseSizeTB * 100)
-		}
-	default:
-		weight = *nodeRules.Weight
+// UnmarshalYAML implements the yaml.Unmarshaler interface to accept the deprecated disk_size_tb.
+func (nodeRules *NodeRules) UnmarshalYAML(unmarshal func(any) error) error {
+	type Plain NodeRules
+	var data struct {
+		Plain      `yaml:",inline"`
+		DiskSizeTB *float64 `yaml:"disk_size_tb"`
 	}
-
-	if weight == 0 {
-		logg.Info("node.Weight %+v ruleData.BaseSizeTB %+v", nodeRules.Weight, baseSizeTB)
+	if err := unmarshal(&data); err != nil {
+		return err
 	}
+	*nodeRules = NodeRules(data.Plain)
+	return resolveDeprecatedSize(&nodeRules.DiskSize, data.DiskSizeTB, "disk_size")
+}
 
-	return weight
+// resolveDeprecatedSize sets a size from its deprecated alias with the suffix _tb which is a bare number of TB
+func resolveDeprecatedSize(size *misc.ByteSize, sizeTB *float64, key string) error {
+	if sizeTB == nil {
+		return nil
+	}
+	if *size != 0 {
+		return fmt.Errorf("%s and %s_tb cannot be used together", key, key)
+	}
+	parsed, err := misc.ParseByteSize(strconv.FormatFloat(*sizeTB, 'f', -1, 64) + "TB")
+	if err != nil {
+		return fmt.Errorf("invalid %s_tb: %w", key, err)
+	}
+	*size = parsed
+	return nil
 }
 
 // ZoneRules contains multiple nodes
 type ZoneRules struct {
-	Nodes map[string]*NodeRules
+	// Defaults apply to all nodes of the zone unless their profile or the node itself overrides them.
+	Defaults *NodeRules `yaml:"defaults,omitempty"`
+	Nodes    map[string]*NodeRules
 }
 
+// getNodeIPs returns the IPs of all nodes of the zone with node ranges expanded
 func (zoneRules ZoneRules) getNodeIPs() []string {
-	var nodeIPs []string
-	for nodeIP := range zoneRules.Nodes {
-		nodeIPs = append(nodeIPs, nodeIP)
-	}
-	sort.Strings(nodeIPs) // for reproducibility in tests
+	return sortedNodeIPs(zoneRules.nodes())
+}
+
+var diskNameRx = regexp.MustCompile(`^swift-(\d+)$`)
+
+// DiskName returns the device name of a disk like "swift-01"
+func DiskName(diskNumber uint64) string {
+	return fmt.Sprintf("swift-%02d", diskNumber)
+}
 
-	return nodeIPs
+// ParseDiskNumber returns the number of a device name like "swift-01"
+func ParseDiskNumber(diskName string) (uint64, bool) {
+	match := diskNameRx.FindStringSubmatch(diskName)
+	if match == nil {
+		return 0, false
+	}
+	diskNumber, err := strconv.ParseUint(match[1], 10, 64)
+	return diskNumber, err == nil && diskNumber > 0
 }
 
 type discoveredDisk struct {
@@ -77,11 +111,49 @@ func getDiscoveredDisk(nodeIP string, diskPort uint64, diskName string) discover
 
 // RingRules containing the rules for a region, multiple Zones and dozzens Nodes
 type RingRules struct {
-	BaseSizeTB float64 `yaml:"base_size_tb"`
-	BasePort   uint64  `yaml:"base_port"`
-	Region     uint64
-	Overload   float64
-	Zones      map[uint64]*ZoneRules
+	// BaseSize is the disk size which gets a weight of 100. Sizes can be given like 6TB or 14TiB.
+	// The deprecated base_size_tb is accepted for sizes as bare number of TB.
+	BaseSize misc.ByteSize `yaml:"base_size,omitempty"`
+	BasePort uint64        `yaml:"base_port"`
+	Region   uint64
+	Overload float64
+	// WeightPolicy selects how the weights of the disks are calculated, see WeightStrategies. Defaults to relative.
+	// The absolute policy uses the disk size in GB as weight.
+	WeightPolicy string `yaml:"weight_policy,omitempty"`
+	// ReservePercent is the share of the disk capacity that is not usable, e.g. because of fallocate_reserve. Used by the usable weight policy.
+	ReservePercent float64 `yaml:"reserve_percent,omitempty"`
+	// Replicas is the desired replica count. Fractional values are supported by swift. Zero leaves the replica count unmanaged.
+	Replicas float64 `yaml:"replicas,omitempty"`
+	// ReplicaStep limits how much the replica count is changed per apply run to spread the data movement.
+	ReplicaStep float64 `yaml:"replica_step,omitempty"`
+	// MinPartHours is the minimum number of hours before a partition can be reassigned again. Nil leaves it unmanaged.
+	MinPartHours *uint64 `yaml:"min_part_hours,omitempty"`
+	// PartPower is the partition power of the ring. It is required to create a new builder file.
+	// Raising it on an existing ring starts swift's partition power increase workflow.
+	PartPower uint64 `yaml:"part_power,omitempty"`
+	// MaxBalance is the highest balance a rebalance may leave behind. Zero disables the check.
+	MaxBalance float64 `yaml:"max_balance,omitempty"`
+	// MaxDispersion is the highest dispersion a rebalance may leave behind. Zero disables the check.
+	MaxDispersion float64 `yaml:"max_dispersion,omitempty"`
+	// MaxPartitionMovementPercent limits the share of partition replicas a single apply run may move. Zero disables the check.
+	MaxPartitionMovementPercent float64 `yaml:"max_partition_movement_percent,omitempty"`
+	// Defaults apply to the nodes of all zones unless the zone defaults, their profile or the node itself override them.
+	Defaults *NodeRules `yaml:"defaults,omitempty"`
+	Zones    map[uint64]*ZoneRules
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface to accept the deprecated base_size_tb.
+func (ringRules *RingRules) UnmarshalYAML(unmarshal func(any) error) error {
+	type Plain RingRules
+	var data struct {
+		Plain      `yaml:",inline"`
+		BaseSizeTB *float64 `yaml:"base_size_tb"`
+	}
+	if err := unmarshal(&data); err != nil {
+		return err
+	}
+	*ringRules = RingRules(data.Plain)
+	return resolveDeprecatedSize(&ringRules.BaseSize, data.BaseSizeTB, "base_size")
 }
 
 func (ringRules RingRules) getZones() []uint64 {
@@ -95,14 +167,126 @@ func (ringRules RingRules) getZones() []uint64 {
 	return zones
 }
 
+// FindNode returns the zone and rules of a node.
+// The rules of a node which is covered by a node range are shared with the other nodes of the range,
+// use EditNode to change them.
+func (ringRules RingRules) FindNode(nodeIP string) (zone uint64, nodeRules *NodeRules, ok bool) {
+	for _, zone := range ringRules.getZones() {
+		if nodeRules, ok := ringRules.Zones[zone].nodes()[nodeIP]; ok {
+			return zone, nodeRules, true
+		}
+	}
+	return 0, nil, false
+}
+
+// EditNode returns the rules of a node for changing them.
+// If the node is only covered by a node range, an exception with a copy of the rules of the range is added for it.
+func (ringRules RingRules) EditNode(nodeIP string) (*NodeRules, bool) {
+	zone, nodeRules, ok := ringRules.FindNode(nodeIP)
+	if !ok {
+		return nil, false
+	}
+	zoneRules := ringRules.Zones[zone]
+	if own, ok := zoneRules.Nodes[nodeIP]; ok && own != nil {
+		return own, true
+	}
+	exception := &NodeRules{}
+	if nodeRules != nil {
+		*exception = nodeRules.overrideWith(NodeRules{})
+	}
+	zoneRules.Nodes[nodeIP] = exception
+	return exception, true
+}
+
+// nodePort returns the port of the disks of a node
+func (ringRules RingRules) nodePort(nodeRules NodeRules) uint64 {
+	switch {
+	case nodeRules.Port != 0:
+		return nodeRules.Port
+	case ringRules.BasePort != 0:
+		return ringRules.BasePort
+	default:
+		return 6000
+	}
+}
+
+// nextReplicas returns the replica count that should be set next.
+// If ReplicaStep is set, the replica count is changed gradually by at most one step per run.
+func (ringRules RingRules) nextReplicas(ring builderfile.RingInfo) (replicas float64, changed bool) {
+	diff := ringRules.Replicas - ring.Replicas
+	if ringRules.Replicas == 0 || math.Abs(diff) <= 0.000001 {
+		return ring.Replicas, false
+	}
+	if ringRules.ReplicaStep <= 0 || math.Abs(diff) <= ringRules.ReplicaStep {
+		return ringRules.Replicas, true
+	}
+	if diff > 0 {
+		return ring.Replicas + ringRules.ReplicaStep, true
+	}
+	return ring.Replicas - ringRules.ReplicaStep, true
+}
+
+// CalculateCreation generates the commands to create a new builder file and to add all devices to it.
+// The new ring still needs to be rebalanced afterwards.
+func (ringRules RingRules) CalculateCreation(ringFilename string) (commandQueue, confirmations []string, err error) {
+	var missing []string
+	if ringRules.PartPower == 0 {
+		missing = append(missing, "part_power")
+	}
+	if ringRules.Replicas == 0 {
+		missing = append(missing, "replicas")
+	}
+	if ringRules.MinPartHours == nil {
+		missing = append(missing, "min_part_hours")
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("creating a new ring requires %s to be set", strings.Join(missing, ", "))
+	}
+
+	commandQueue = append(commandQueue, builderfile.CommandCreate(ringFilename, ringRules.PartPower, ringRules.Replicas, *ringRules.MinPartHours))
+
+	// the state of the ring right after the create command
+	ring := builderfile.RingInfo{
+		Partitions:         1 << ringRules.PartPower,
+		Replicas:           ringRules.Replicas,
+		ReassignedCooldown: *ringRules.MinPartHours,
+	}
+	changes, confirmations, err := ringRules.CalculateChanges(ring, ringFilename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(commandQueue, changes...), confirmations, nil
+}
+
 // CalculateChanges to parsed MetaData
 func (ringRules RingRules) CalculateChanges(ring builderfile.RingInfo, ringFilename string) (commandQueue, confirmations []string, err error) {
-	if ring.Regions == 0 {
-		return nil, nil, errors.New("regions needs to be set")
-	} else if ringRules.Region != ring.Regions || ring.Regions != 1 {
+	if err := ringRules.checkWeightUnits(); err != nil {
+		return nil, nil, err
+	}
+
+	// an empty ring has no regions yet
+	if len(ring.Devices) > 0 {
+		if ring.Regions == 0 {
+			return nil, nil, errors.New("regions needs to be set")
+		} else if ringRules.Region != ring.Regions || ring.Regions != 1 {
+			return nil, nil, errors.New("currently only one region is supported")
+		}
+	} else if ringRules.Region != 1 {
 		return nil, nil, errors.New("currently only one region is supported")
 	}
 
+	partPowerPlan, err := ringRules.PartPowerPlan(ring, ringFilename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if partPowerPlan.Phase != PartPowerPhaseNone {
+		// swift does not allow rebalancing while the partition power is increased, therefore all other changes need to wait
+		logg.Info("Partition power increase from %d to %d is in phase %q, other changes are postponed until it is finished",
+			partPowerPlan.CurrentPartPower, partPowerPlan.TargetPartPower, partPowerPlan.Phase)
+		return []string{partPowerPlan.Command}, []string{partPowerPlan.Confirmation}, nil
+	}
+
 	var discoveredDisks []discoveredDisk
 
 	// Special handling for floating point comparison
@@ -111,29 +295,44 @@ func (ringRules RingRules) CalculateChanges(ring builderfile.RingInfo, ringFilen
 		commandQueue = append(commandQueue, ring.CommandSetOverload(ringFilename, ringRules.Overload))
 	}
 
+	if ringRules.MinPartHours != nil && *ringRules.MinPartHours != ring.ReassignedCooldown {
+		logg.Debug("min_part_hours does not match, adding command to change it from %d to %d", ring.ReassignedCooldown, *ringRules.MinPartHours)
+		commandQueue = append(commandQueue, ring.CommandSetMinPartHours(ringFilename, *ringRules.MinPartHours))
+	}
+
+	if replicas, changed := ringRules.nextReplicas(ring); changed {
+		logg.Debug("Replicas do not match, adding command to change them from %g to %g", ring.Replicas, replicas)
+		commandQueue = append(commandQueue, ring.CommandSetReplicas(ringFilename, replicas))
+		action := "adds"
+		if replicas < ring.Replicas {
+			action = "removes"
+		}
+		movedReplicas := math.Abs(replicas-ring.Replicas) * float64(ring.Partitions)
+		msg := fmt.Sprintf("Changing the replica count from %g to %g %s %.0f partition replicas which moves about %.1f%% of the stored data. Do you want to continue?",
+			ring.Replicas, replicas, action, movedReplicas, math.Abs(replicas-ring.Replicas)/ring.Replicas*100)
+		confirmations = append(confirmations, msg)
+	}
+
 	zones := ringRules.getZones()
 	for _, zone := range zones {
 		zoneRules := ringRules.Zones[zone]
 
-		for _, nodeIP := range zoneRules.getNodeIPs() {
-			nodeRules := zoneRules.Nodes[nodeIP]
+		nodes := zoneRules.nodes()
+		for _, nodeIP := range sortedNodeIPs(nodes) {
+			nodeRules := nodes[nodeIP]
 
 			for diskNumber := uint64(1); diskNumber <= nodeRules.DiskCount; diskNumber++ {
-				diskName := fmt.Sprintf("swift-%02d", diskNumber)
+				diskName := DiskName(diskNumber)
 				if slices.Contains(nodeRules.BrokenDisks, diskName) {
 					continue
 				}
 
-				weight := nodeRules.DesiredWeight(ringRules.BaseSizeTB, nodeIP)
-				var port uint64
-				switch {
-				case nodeRules.Port != 0:
-					port = nodeRules.Port
-				case ringRules.BasePort != 0:
-					port = ringRules.BasePort
-				default:
-					port = 6000
+				weight, explanation, err := ringRules.DiskWeight(*nodeRules, nodeIP, diskName)
+				if err != nil {
+					return nil, nil, err
 				}
+				logg.Debug("Desired weight of disk %s on node %s is %g (%s)", diskName, nodeIP, weight, explanation)
+				port := ringRules.nodePort(*nodeRules)
 				disk, err := ring.FindDevice(zone, nodeIP, port, diskName)
 				if err != nil {
 					return nil, nil, err
@@ -247,6 +247,24 @@ func TestDeleteBrokenDisk1(t *testing.T) {
 	assert.DeepEqual(t, "parsing", confirmations, []string(nil))
 }
 
+func TestZeroWeightDisk1(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-zero-weight-1.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "parsing", commandQueue, []string{
+		"swift-ring-builder /dev/null set_weight --region 1 --zone 1 --ip 10.114.1.203 --port 6001 --device swift-02 --weight 100 0",
+	})
+	assert.DeepEqual(t, "parsing", confirmations, []string(nil))
+}
+
 func TestSetOverload(t *testing.T) {
 	var input builderfile.RingInfo
 	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
@@ -265,6 +283,100 @@ func TestSetOverload(t *testing.T) {
 	assert.DeepEqual(t, "parsing", confirmations, []string(nil))
 }
 
+func TestSetMinPartHours(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-min-part-hours.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "parsing", commandQueue, []string{
+		"swift-ring-builder /dev/null set_min_part_hours 1",
+	})
+	assert.DeepEqual(t, "parsing", confirmations, []string(nil))
+
+	// no change is generated when the value already matches
+	minPartHours := input.ReassignedCooldown
+	ring.MinPartHours = &minPartHours
+	commandQueue, _, err = ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "parsing", commandQueue, []string(nil))
+}
+
+func TestSetReplicas(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-replicas.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "parsing", commandQueue, []string{
+		"swift-ring-builder /dev/null set_replicas 3.5",
+	})
+	assert.DeepEqual(t, "parsing", confirmations, []string{
+		"Changing the replica count from 3 to 3.5 adds 512 partition replicas which moves about 16.7% of the stored data. Do you want to continue?",
+	})
+}
+
+func TestSetReplicasStep(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-replicas-step.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateChanges(input, "/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "parsing", commandQueue, []string{
+		"swift-ring-builder /dev/null set_replicas 2.5",
+	})
+	assert.DeepEqual(t, "parsing", confirmations, []string{
+		"Changing the replica count from 3 to 2.5 removes 512 partition replicas which moves about 16.7% of the stored data. Do you want to continue?",
+	})
+}
+
+func TestCreateRing(t *testing.T) {
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-create.yaml", &ring)
+
+	commandQueue, confirmations, err := ring.CalculateCreation("/dev/null")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "parsing", commandQueue, []string{
+		"swift-ring-builder /dev/null create 10 3 24",
+		"swift-ring-builder /dev/null add --region 1 --zone 1 --ip 10.114.1.202 --port 6001 --device swift-01 --weight 100",
+		"swift-ring-builder /dev/null add --region 1 --zone 1 --ip 10.114.1.202 --port 6001 --device swift-02 --weight 100",
+		"swift-ring-builder /dev/null add --region 1 --zone 2 --ip 10.114.1.203 --port 6001 --device swift-01 --weight 100",
+		"swift-ring-builder /dev/null add --region 1 --zone 2 --ip 10.114.1.203 --port 6001 --device swift-02 --weight 100",
+	})
+	assert.DeepEqual(t, "parsing", confirmations, []string(nil))
+}
+
+func TestCreateRingMissingParameters(t *testing.T) {
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &ring)
+
+	_, _, err := ring.CalculateCreation("/dev/null")
+	assert.ErrEqual(t, err, "creating a new ring requires part_power, replicas, min_part_hours to be set")
+}
+
 func TestZoneMismatch(t *testing.T) {
 	var input builderfile.RingInfo
 	misc.ReadYAML("../../testing/builder-output-zone-mismatch.yaml", &input)
@@ -332,3 +444,25 @@ func TestPortMismatch(t *testing.T) {
 		t.Fatalf("Expected %q but got %q", errString, err.Error())
 	}
 }
+
+func TestSharedNodes(t *testing.T) {
+	var file map[string]RingRules
+	misc.ReadYAML("../../testing/artisan-multi-ring-1.yaml", &file)
+
+	assert.DeepEqual(t, "ring names", GetRingNames(file), []string{"account.builder", "container.builder", "object-1.builder"})
+	assert.DeepEqual(t, "errors", CheckSharedNodes(file), []error(nil))
+}
+
+func TestSharedNodesMismatch(t *testing.T) {
+	var file map[string]RingRules
+	misc.ReadYAML("../../testing/artisan-multi-ring-error.yaml", &file)
+
+	var errStrings []string
+	for _, err := range CheckSharedNodes(file) {
+		errStrings = append(errStrings, err.Error())
+	}
+	assert.DeepEqual(t, "errors", errStrings, []string{
+		"node 10.114.1.202 is in region 1 zone 1 in account.builder but in region 1 zone 2 in container.builder",
+		"hostname node202 has IP 10.114.1.202 in account.builder but IP 10.114.1.204 in object-1.builder",
+	})
+}
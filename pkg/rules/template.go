@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+// templateData is available as dot in rule file templates
+type templateData struct {
+	// Values are read from the values file given with --values
+	Values map[string]any
+}
+
+// templateFuncs are the functions that can be used in rule file templates in addition to the builtin ones
+var templateFuncs = template.FuncMap{
+	// env returns the value of an environment variable and fails if it is not set
+	"env": func(name string) (string, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	},
+	// envOr returns the value of an environment variable or the fallback if it is not set
+	"envOr": func(name, fallback string) string {
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return fallback
+	},
+	// seq returns the numbers from first to last, e.g. seq 1 3 returns [1 2 3]
+	"seq": func(first, last int) []int {
+		var numbers []int
+		for i := first; i <= last; i++ {
+			numbers = append(numbers, i)
+		}
+		return numbers
+	},
+	// ipRange returns count consecutive IPs starting with first, e.g. ipRange "10.0.0.254" 3 returns [10.0.0.254 10.0.0.255 10.0.1.0]
+	"ipRange": func(first string, count int) ([]string, error) {
+		addr, err := netip.ParseAddr(first)
+		if err != nil {
+			return nil, err
+		}
+		ips := make([]string, 0, count)
+		for range count {
+			if !addr.IsValid() {
+				return nil, fmt.Errorf("ipRange %s %d exceeds the address space", first, count)
+			}
+			ips = append(ips, addr.String())
+			addr = addr.Next()
+		}
+		return ips, nil
+	},
+	"add": func(a, b int) int { return a + b },
+}
+
+// TemplateMarker is the first line of a rule file which is rendered as Go template even without a values file
+const TemplateMarker = "# swift-ring-artisan: template"
+
+// IsTemplate returns true if a rule file opts in to be rendered as Go template by starting with the TemplateMarker
+func IsTemplate(data []byte) bool {
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	return string(bytes.TrimSpace(firstLine)) == TemplateMarker
+}
+
+// ReadValues reads a values file for rule file templates. Returns nil if no values file is given.
+func ReadValues(filename string) map[string]any {
+	if filename == "" {
+		return nil
+	}
+	values := make(map[string]any)
+	misc.ReadYAML(filename, &values)
+	return values
+}
+
+// renderTemplate renders a rule file as Go template. Missing values are reported as errors.
+func renderTemplate(filename string, data []byte, values map[string]any) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(filename)).Option("missingkey=error").Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{Values: values}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"github.com/sapcc/go-bits/must"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Setenv("ARTISAN_DATACENTER", "dc1")
+	values := ReadValues("../../testing/artisan-values.yaml")
+	rings := must.Return(must.Return(readFiles("../../testing/artisan-rules-template.yaml", values)).Resolve())
+
+	meta := &map[string]string{"datacenter": "dc1"}
+	node := &NodeRules{Meta: meta, DiskCount: 12}
+	assert.DeepEqual(t, "rendered rules", rings["object.builder"], RingRules{
+		BaseSize: 6 * misc.Terabyte,
+		BasePort: 6001,
+		Region:   1,
+		Zones: map[uint64]*ZoneRules{
+			1: {Nodes: map[string]*NodeRules{"10.114.1.254": node, "10.114.1.255": node, "10.114.2.0": node}},
+			2: {Nodes: map[string]*NodeRules{"10.114.2.10": node}},
+		},
+	})
+}
+
+func TestTemplateErrors(t *testing.T) {
+	// t.Setenv restores the environment after the test
+	t.Setenv("ARTISAN_DATACENTER", "")
+	must.SucceedT(t, os.Unsetenv("ARTISAN_DATACENTER"))
+	values := ReadValues("../../testing/artisan-values.yaml")
+	_, err := readFiles("../../testing/artisan-rules-template.yaml", values)
+	assert.ErrEqual(t, err, `rendering ../../testing/artisan-rules-template.yaml failed: template: artisan-rules-template.yaml:9:21: executing "artisan-rules-template.yaml" at <env "ARTISAN_DATACENTER">: error calling env: environment variable ARTISAN_DATACENTER is not set`)
+
+	t.Setenv("ARTISAN_DATACENTER", "dc1")
+	_, err = readFiles("../../testing/artisan-rules-template.yaml", nil)
+	assert.ErrEqual(t, err, `rendering ../../testing/artisan-rules-template.yaml failed: template: artisan-rules-template.yaml:4:23: executing "artisan-rules-template.yaml" at <.Values.base_port>: map has no entry for key "base_port"`)
+}
+
+func TestTemplateOptIn(t *testing.T) {
+	// files without the marker are only rendered if values are given
+	rings := must.Return(must.Return(readFiles("../../testing/artisan-rules-braces.yaml", nil)).Resolve())
+	assert.DeepEqual(t, "meta", *rings["object.builder"].Zones[1].Nodes["10.114.1.202"].Meta, map[string]string{"owner": "{{ not a template }}"})
+
+	_, err := readFiles("../../testing/artisan-rules-braces.yaml", map[string]any{})
+	assert.ErrEqual(t, err, `rendering ../../testing/artisan-rules-braces.yaml failed: template: artisan-rules-braces.yaml:11: function "a" not defined`)
+
+	assert.Equal(t, IsTemplate([]byte(TemplateMarker+"\nobject.builder: {}")), true)
+	assert.Equal(t, IsTemplate([]byte("object.builder: {}\n"+TemplateMarker)), false)
+}
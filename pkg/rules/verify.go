@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+)
+
+// VerifyThresholds are limits a ring needs to stay within after a rebalance. Zero disables a check.
+type VerifyThresholds struct {
+	MaxBalance                  float64
+	MaxDispersion               float64
+	MaxPartitionMovementPercent float64
+}
+
+// maxListedOffenders limits how many devices, tiers or moves are listed when a threshold is exceeded
+const maxListedOffenders = 10
+
+// thresholds returns the limits of the ring rules, the overrides take precedence if they are set
+func (ringRules RingRules) thresholds(overrides VerifyThresholds) VerifyThresholds {
+	thresholds := VerifyThresholds{
+		MaxBalance:                  ringRules.MaxBalance,
+		MaxDispersion:               ringRules.MaxDispersion,
+		MaxPartitionMovementPercent: ringRules.MaxPartitionMovementPercent,
+	}
+	if overrides.MaxBalance != 0 {
+		thresholds.MaxBalance = overrides.MaxBalance
+	}
+	if overrides.MaxDispersion != 0 {
+		thresholds.MaxDispersion = overrides.MaxDispersion
+	}
+	if overrides.MaxPartitionMovementPercent != 0 {
+		thresholds.MaxPartitionMovementPercent = overrides.MaxPartitionMovementPercent
+	}
+	return thresholds
+}
+
+// listOffenders formats the offenders of an exceeded threshold as indented lines below the problem
+func listOffenders(problem string, offenders []string) string {
+	if len(offenders) == 0 {
+		return problem
+	}
+	problem += ":"
+	if len(offenders) > maxListedOffenders {
+		offenders = append(offenders[:maxListedOffenders:maxListedOffenders], fmt.Sprintf("and %d more", len(offenders)-maxListedOffenders))
+	}
+	for _, offender := range offenders {
+		problem += "\n    " + offender
+	}
+	return problem
+}
+
+// isStagedCommand returns true for commands which are intentionally spread over multiple apply runs like stepwise
+// replica changes and the phases of a partition power increase
+func isStagedCommand(command string) bool {
+	return strings.Contains(command, "set_replicas") || strings.Contains(command, "partition_power")
+}
+
+// pendingProblems describes why the plan is not empty after apply. The drift describes the differences best,
+// but the plan needs to be empty even for changes which are not modelled as drift, then the commands are listed.
+func pendingProblems(drift []Drift, remaining []string) []string {
+	var problems []string
+	for _, d := range drift {
+		problems = append(problems, fmt.Sprintf("%s: %s", d.Category, d))
+	}
+	if len(drift) == 0 {
+		for _, command := range remaining {
+			problems = append(problems, "command still pending: "+command)
+		}
+	}
+	return problems
+}
+
+// VerifyApplied checks that a ring matches the rules after apply executed all commands.
+// Changes that are intentionally spread over multiple apply runs are ignored.
+// If the ring was rebalanced, it also needs to stay within the max_balance, max_dispersion and
+// max_partition_movement_percent of the rules, which can be overridden by the given thresholds.
+func (ringRules RingRules) VerifyApplied(before, after builderfile.RingInfo, ringFilename string, overrides VerifyThresholds, rebalanced bool) error {
+	commandQueue, _, err := ringRules.CalculateChanges(after, ringFilename)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	remaining := slices.DeleteFunc(commandQueue, isStagedCommand)
+	if len(remaining) > 0 {
+		drift, err := ringRules.Drift(after)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, pendingProblems(drift, remaining)...)
+	}
+
+	if rebalanced {
+		thresholds := ringRules.thresholds(overrides)
+
+		balances, balance := after.DeviceBalances()
+		if thresholds.MaxBalance != 0 && balance > thresholds.MaxBalance {
+			var offenders []string
+			for _, device := range after.Devices {
+				if math.Abs(balances[device.ID]) > thresholds.MaxBalance {
+					offenders = append(offenders, fmt.Sprintf("%s: balance %g", device.Label(), balances[device.ID]))
+				}
+			}
+			problems = append(problems, listOffenders(fmt.Sprintf("balance is %g but must not exceed %g", balance, thresholds.MaxBalance), offenders))
+		}
+
+		if thresholds.MaxDispersion != 0 && after.Dispersion > thresholds.MaxDispersion {
+			var offenders []string
+			for _, tier := range after.UndispersedTiers() {
+				offenders = append(offenders, fmt.Sprintf("%s: %d partitions with too many replicas", tier.Tier, tier.Partitions))
+			}
+			problems = append(problems, listOffenders(fmt.Sprintf("dispersion is %g but must not exceed %g", after.Dispersion, thresholds.MaxDispersion), offenders))
+		}
+
+		// the movement of a new ring or of a ring read from swift-ring-builder output is unknown
+		if thresholds.MaxPartitionMovementPercent != 0 && len(before.Replica2Part2Dev) > 0 && len(after.Replica2Part2Dev) > 0 {
+			movement, err := builderfile.CalculatePartitionMovement(before, after)
+			if err != nil {
+				return err
+			}
+			if movement.MovedPercent() > thresholds.MaxPartitionMovementPercent {
+				var offenders []string
+				for _, moves := range movement.Zones {
+					offenders = append(offenders, fmt.Sprintf("%s -> %s: %d partition replicas", moves.Source, moves.Target, moves.Replicas))
+				}
+				problems = append(problems, listOffenders(fmt.Sprintf("%.2f%% of the partition replicas moved but at most %g%% may move",
+					movement.MovedPercent(), thresholds.MaxPartitionMovementPercent), offenders))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s does not pass the verification after applying the changes:\n  %s", ringFilename, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestVerifyApplied(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &ring)
+
+	err := ring.VerifyApplied(input, input, "/dev/null", VerifyThresholds{MaxBalance: 1, MaxDispersion: 1}, true)
+	assert.ErrEqual(t, err, nil)
+}
+
+func TestVerifyAppliedDrift(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-changes-1.yaml", &ring)
+
+	err := ring.VerifyApplied(input, input, "/dev/null", VerifyThresholds{}, true)
+	assert.ErrEqual(t, err, `/dev/null does not pass the verification after applying the changes:
+  weight: z1 10.114.1.203/swift-01: weight is 100 but should be 166 (-39.8%)
+  weight: z1 10.114.1.203/swift-02: weight is 100 but should be 166 (-39.8%)
+  weight: z1 10.114.1.203/swift-03: weight is 100 but should be 166 (-39.8%)`)
+}
+
+func TestVerifyAppliedThresholds(t *testing.T) {
+	var input builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &input)
+	input.Dispersion = 2.5
+	input.Devices[0].Partitions = 600
+	input.Devices[1].Partitions = 424
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &ring)
+	ring.MaxBalance = 1
+	ring.MaxDispersion = 1
+
+	// the thresholds given on the command line take precedence over the rules
+	overrides := VerifyThresholds{MaxBalance: 5}
+	err := ring.VerifyApplied(input, input, "/dev/null", overrides, true)
+	assert.ErrEqual(t, err, `/dev/null does not pass the verification after applying the changes:
+  balance is 17.19 but must not exceed 5:
+    z1 10.114.1.202:6001/swift-01: balance 17.19
+    z1 10.114.1.202:6001/swift-02: balance -17.19
+  dispersion is 2.5 but must not exceed 1`)
+
+	// a ring which was not rebalanced cannot be expected to be balanced
+	err = ring.VerifyApplied(input, input, "/dev/null", overrides, false)
+	assert.ErrEqual(t, err, nil)
+}
+
+func TestVerifyAppliedMovement(t *testing.T) {
+	var before builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &before)
+	before.Replica2Part2Dev = [][]uint64{{0, 1, 2, 3}, {3, 4, 5, 0}, {1, 2, 3, 4}}
+	after := before
+	after.Replica2Part2Dev = [][]uint64{{5, 1, 2, 3}, {3, 4, 5, 0}, {1, 2, 3, 4}}
+
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-1.yaml", &ring)
+	ring.MaxPartitionMovementPercent = 5
+
+	err := ring.VerifyApplied(before, after, "/dev/null", VerifyThresholds{}, true)
+	assert.ErrEqual(t, err, `/dev/null does not pass the verification after applying the changes:
+  8.33% of the partition replicas moved but at most 5% may move:
+    r1z1 -> r1z1: 1 partition replicas`)
+
+	err = ring.VerifyApplied(before, after, "/dev/null", VerifyThresholds{MaxPartitionMovementPercent: 10}, true)
+	assert.ErrEqual(t, err, nil)
+}
+
+func TestPendingProblems(t *testing.T) {
+	drift := []Drift{{Category: DriftOverload, Message: "overload is 0 but should be 0.1"}}
+	remaining := []string{"swift-ring-builder /dev/null set_overload 0.1"}
+	assert.DeepEqual(t, "problems", pendingProblems(drift, remaining), []string{
+		"overload: overload is 0 but should be 0.1",
+	})
+
+	// commands which are not modelled as drift still fail the verification
+	assert.DeepEqual(t, "problems", pendingProblems(nil, remaining), []string{
+		"command still pending: swift-ring-builder /dev/null set_overload 0.1",
+	})
+}
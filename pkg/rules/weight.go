@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+// WeightStrategy calculates the weight of the disks of a node
+type WeightStrategy interface {
+	// Weight returns the weight of every disk of the node and a human readable explanation how it was calculated
+	Weight(nodeRules NodeRules, ringRules RingRules) (weight float64, explanation string, err error)
+}
+
+// DefaultWeightPolicy is used when neither the ring nor the node select a weight policy
+const DefaultWeightPolicy = "relative"
+
+// WeightStrategies contains all weight policies that can be selected with weight_policy
+var WeightStrategies = map[string]WeightStrategy{
+	"relative": relativeWeight{},
+	"absolute": absoluteWeight{},
+	"usable":   usableWeight{},
+	"reported": reportedWeight{},
+}
+
+// relativeWeight sets the weight relative to the base size, a disk with the base size has a weight of 100
+type relativeWeight struct{}
+
+func (relativeWeight) Weight(nodeRules NodeRules, ringRules RingRules) (weight float64, explanation string, err error) {
+	if ringRules.BaseSize == 0 {
+		return 0, "", errors.New("base_size needs to be set to calculate a relative weight")
+	}
+	if nodeRules.DiskSize == 0 {
+		return 100, "relative: no disk_size given, using the default weight 100", nil
+	}
+	weight = math.Floor(float64(nodeRules.DiskSize) / float64(ringRules.BaseSize) * 100)
+	return weight, fmt.Sprintf("relative: floor(%s / %s base size * 100) = %g", nodeRules.DiskSize, ringRules.BaseSize, weight), nil
+}
+
+// absoluteWeight sets the weight to the disk size in GB (10^9 bytes), rounded down.
+// GB instead of bytes keeps the weights readable in the output of swift-ring-builder, which prints them with
+// two decimals, while still telling apart all disk sizes that are sold.
+type absoluteWeight struct{}
+
+func (absoluteWeight) Weight(nodeRules NodeRules, _ RingRules) (weight float64, explanation string, err error) {
+	if nodeRules.DiskSize == 0 {
+		return 0, "", errors.New("disk_size needs to be set to calculate an absolute weight")
+	}
+	weight = math.Floor(nodeRules.DiskSize.GB())
+	return weight, fmt.Sprintf("absolute: %s = %g GB", nodeRules.DiskSize, weight), nil
+}
+
+// usableWeight is like relativeWeight but only takes the capacity into account which is left after the reserve
+type usableWeight struct{}
+
+func (usableWeight) Weight(nodeRules NodeRules, ringRules RingRules) (weight float64, explanation string, err error) {
+	if ringRules.BaseSize == 0 {
+		return 0, "", errors.New("base_size needs to be set to calculate a usable weight")
+	}
+	if nodeRules.DiskSize == 0 {
+		return 0, "", errors.New("disk_size needs to be set to calculate a usable weight")
+	}
+	if ringRules.ReservePercent < 0 || ringRules.ReservePercent >= 100 {
+		return 0, "", fmt.Errorf("reserve_percent needs to be between 0 and 100 but is %g", ringRules.ReservePercent)
+	}
+	usable := misc.ByteSize(float64(nodeRules.DiskSize) * (100 - ringRules.ReservePercent) / 100)
+	weight = math.Floor(float64(usable) / float64(ringRules.BaseSize) * 100)
+	return weight, fmt.Sprintf("usable: floor(%s - %g%% reserve = %s / %s base size * 100) = %g",
+		nodeRules.DiskSize, ringRules.ReservePercent, usable, ringRules.BaseSize, weight), nil
+}
+
+// reportedWeight passes the weight through that was reported for the node, e.g. by a hardware inventory
+type reportedWeight struct{}
+
+func (reportedWeight) Weight(nodeRules NodeRules, _ RingRules) (weight float64, explanation string, err error) {
+	if nodeRules.ReportedWeight == nil {
+		return 0, "", errors.New("reported_weight needs to be set to use the reported weight policy")
+	}
+	return *nodeRules.ReportedWeight, fmt.Sprintf("reported: reported_weight = %g", *nodeRules.ReportedWeight), nil
+}
+
+// weightPolicy returns the weight policy of a node which is either its own, the one of the ring or the default
+func (ringRules RingRules) weightPolicy(nodeRules NodeRules) string {
+	switch {
+	case nodeRules.WeightPolicy != "":
+		return nodeRules.WeightPolicy
+	case ringRules.WeightPolicy != "":
+		return ringRules.WeightPolicy
+	default:
+		return DefaultWeightPolicy
+	}
+}
+
+// weightUnits are the units of the weights the policies calculate. Reported weights are taken as they are.
+var weightUnits = map[string]string{
+	"relative": "relative to base_size",
+	"usable":   "relative to base_size",
+	"absolute": "GB",
+}
+
+// checkWeightUnits rejects rings whose nodes use weight policies with different units, e.g. absolute and relative.
+// swift distributes the partitions proportionally to the weights, so a disk with an absolute weight of 7680
+// would get about 77 times the data of a disk of the same size with a relative weight of 100.
+// Nodes with an explicit weight are not checked.
+func (ringRules RingRules) checkWeightUnits() error {
+	var firstNodeIP, firstPolicy string
+	for _, zone := range ringRules.getZones() {
+		nodes := ringRules.Zones[zone].nodes()
+		for _, nodeIP := range sortedNodeIPs(nodes) {
+			var nodeRules NodeRules
+			if nodes[nodeIP] != nil {
+				nodeRules = *nodes[nodeIP]
+			}
+			if nodeRules.Weight != nil {
+				continue
+			}
+			policy := ringRules.weightPolicy(nodeRules)
+			unit, ok := weightUnits[policy]
+			switch {
+			case !ok:
+				continue
+			case firstPolicy == "":
+				firstNodeIP, firstPolicy = nodeIP, policy
+			case weightUnits[firstPolicy] != unit:
+				return fmt.Errorf("node %s uses the weight_policy %s whose weights are %s, but node %s uses %s whose weights are %s, policies with different units cannot be mixed in a ring",
+					nodeIP, policy, unit, firstNodeIP, firstPolicy, weightUnits[firstPolicy])
+			}
+		}
+	}
+	return nil
+}
+
+// DesiredWeight calculates the weight of the disks of a node.
+// An explicit weight on the node always takes precedence over the weight policy.
+func (ringRules RingRules) DesiredWeight(nodeRules NodeRules, nodeIP string) (weight float64, explanation string, err error) {
+	if nodeRules.Weight != nil {
+		return *nodeRules.Weight, fmt.Sprintf("explicit: weight = %g", *nodeRules.Weight), nil
+	}
+
+	policy := ringRules.weightPolicy(nodeRules)
+	strategy, ok := WeightStrategies[policy]
+	if !ok {
+		var policies []string
+		for name := range WeightStrategies {
+			policies = append(policies, name)
+		}
+		slices.Sort(policies)
+		return 0, "", fmt.Errorf("node %s: unknown weight_policy %q, must be one of %s", nodeIP, policy, strings.Join(policies, ", "))
+	}
+
+	weight, explanation, err = strategy.Weight(nodeRules, ringRules)
+	if err != nil {
+		return 0, "", fmt.Errorf("node %s: %w", nodeIP, err)
+	}
+	return weight, explanation, nil
+}
+
+// DiskWeight calculates the weight of a disk of a node. Disks listed in zero_weight_disks always have weight 0.
+func (ringRules RingRules) DiskWeight(nodeRules NodeRules, nodeIP, diskName string) (weight float64, explanation string, err error) {
+	if slices.Contains(nodeRules.ZeroWeightDisks, diskName) {
+		return 0, "zero weight: listed in zero_weight_disks", nil
+	}
+	return ringRules.DesiredWeight(nodeRules, nodeIP)
+}
+
+// WeightExplanation describes how the weight of the disks of a node was calculated
+type WeightExplanation struct {
+	Zone        uint64
+	NodeIP      string
+	Weight      float64
+	Explanation string
+}
+
+// ExplainWeights calculates the weights of all nodes and explains how they were calculated
+func (ringRules RingRules) ExplainWeights() ([]WeightExplanation, error) {
+	if err := ringRules.checkWeightUnits(); err != nil {
+		return nil, err
+	}
+	var explanations []WeightExplanation
+	for _, zone := range ringRules.getZones() {
+		zoneRules := ringRules.Zones[zone]
+		nodes := zoneRules.nodes()
+		for _, nodeIP := range sortedNodeIPs(nodes) {
+			weight, explanation, err := ringRules.DesiredWeight(*nodes[nodeIP], nodeIP)
+			if err != nil {
+				return nil, err
+			}
+			explanations = append(explanations, WeightExplanation{Zone: zone, NodeIP: nodeIP, Weight: weight, Explanation: explanation})
+		}
+	}
+	return explanations, nil
+}
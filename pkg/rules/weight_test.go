@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+)
+
+func TestExplainWeights(t *testing.T) {
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-weight-policies.yaml", &ring)
+
+	explanations, err := ring.ExplainWeights()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "explanations", explanations, []WeightExplanation{
+		{Zone: 1, NodeIP: "10.114.1.202", Weight: 90, Explanation: "usable: floor(6TB - 10% reserve = 5.4TB / 6TB base size * 100) = 90"},
+		{Zone: 1, NodeIP: "10.114.1.203", Weight: 100, Explanation: "relative: floor(6TB / 6TB base size * 100) = 100"},
+		{Zone: 2, NodeIP: "10.114.1.204", Weight: 42, Explanation: "reported: reported_weight = 42"},
+		{Zone: 2, NodeIP: "10.114.1.205", Weight: 77, Explanation: "explicit: weight = 77"},
+	})
+}
+
+func TestExplainWeightsByteUnits(t *testing.T) {
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-weight-units.yaml", &ring)
+
+	explanations, err := ring.ExplainWeights()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.DeepEqual(t, "explanations", explanations, []WeightExplanation{
+		{Zone: 1, NodeIP: "10.114.1.202", Weight: 7680, Explanation: "absolute: 7.68TB = 7680 GB"},
+		{Zone: 1, NodeIP: "10.114.1.203", Weight: 15393, Explanation: "absolute: 14TiB = 15393 GB"},
+		{Zone: 1, NodeIP: "10.114.1.204", Weight: 960, Explanation: "absolute: 960GB = 960 GB"},
+	})
+}
+
+func TestMixedWeightUnits(t *testing.T) {
+	var ring RingRules
+	misc.ReadYAML("../../testing/artisan-rules-weight-units.yaml", &ring)
+	ring.Zones[1].Nodes["10.114.1.204"].WeightPolicy = "relative"
+
+	_, err := ring.ExplainWeights()
+	assert.ErrEqual(t, err, "node 10.114.1.204 uses the weight_policy relative whose weights are relative to base_size, but node 10.114.1.202 uses absolute whose weights are GB, policies with different units cannot be mixed in a ring")
+	_, err = ring.Resolve(nil)
+	assert.ErrEqual(t, err, "node 10.114.1.204 uses the weight_policy relative whose weights are relative to base_size, but node 10.114.1.202 uses absolute whose weights are GB, policies with different units cannot be mixed in a ring")
+
+	// explicit weights are taken as they are
+	weight := 100.0
+	ring.Zones[1].Nodes["10.114.1.204"].Weight = &weight
+	_, err = ring.Resolve(nil)
+	assert.ErrEqual(t, err, nil)
+}
+
+func TestDeprecatedSizeKeys(t *testing.T) {
+	var ring RingRules
+	err := yaml.UnmarshalStrict([]byte("base_size_tb: 6\nzones: {1: {nodes: {10.114.1.202: {disk_count: 3, disk_size_tb: 7.68}}}}"), &ring)
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, ring.BaseSize, 6*misc.Terabyte)
+	assert.Equal(t, ring.Zones[1].Nodes["10.114.1.202"].DiskSize, 7680*misc.Gigabyte)
+
+	// the deprecated keys only accept a bare number of TB
+	err = yaml.UnmarshalStrict([]byte("base_size_tb: 6TB"), &ring)
+	assert.ErrEqual(t, err, "yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `6TB` into float64")
+
+	err = yaml.UnmarshalStrict([]byte("base_size: 6TB\nbase_size_tb: 6"), &ring)
+	assert.ErrEqual(t, err, "base_size and base_size_tb cannot be used together")
+
+	var node NodeRules
+	err = yaml.UnmarshalStrict([]byte("disk_size: 6TB\ndisk_size_tb: 6"), &node)
+	assert.ErrEqual(t, err, "disk_size and disk_size_tb cannot be used together")
+}
+
+func TestDesiredWeightRelative(t *testing.T) {
+	ring := RingRules{BaseSize: 6 * misc.Terabyte}
+
+	weight, _, err := ring.DesiredWeight(NodeRules{DiskSize: 10 * misc.Terabyte}, "10.114.1.202")
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, weight, 166.0)
+
+	weight, _, err = ring.DesiredWeight(NodeRules{}, "10.114.1.202")
+	assert.ErrEqual(t, err, nil)
+	assert.Equal(t, weight, 100.0)
+}
+
+func TestDesiredWeightErrors(t *testing.T) {
+	_, _, err := RingRules{}.DesiredWeight(NodeRules{DiskSize: 6 * misc.Terabyte}, "10.114.1.202")
+	assert.ErrEqual(t, err, "node 10.114.1.202: base_size needs to be set to calculate a relative weight")
+
+	_, _, err = RingRules{BaseSize: 6 * misc.Terabyte, WeightPolicy: "magic"}.DesiredWeight(NodeRules{DiskSize: 6 * misc.Terabyte}, "10.114.1.202")
+	assert.ErrEqual(t, err, `node 10.114.1.202: unknown weight_policy "magic", must be one of absolute, relative, reported, usable`)
+
+	_, _, err = RingRules{WeightPolicy: "reported"}.DesiredWeight(NodeRules{}, "10.114.1.202")
+	assert.ErrEqual(t, err, "node 10.114.1.202: reported_weight needs to be set to use the reported weight policy")
+
+	_, _, err = RingRules{BaseSize: 6 * misc.Terabyte, WeightPolicy: "usable", ReservePercent: 100}.DesiredWeight(NodeRules{DiskSize: 6 * misc.Terabyte}, "10.114.1.202")
+	assert.ErrEqual(t, err, "node 10.114.1.202: reserve_percent needs to be between 0 and 100 but is 100")
+}
@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package swiftconf
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sapcc/go-bits/logg"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+// PolicyRulePrefix marks keys in a rule file which refer to a storage policy instead of a builder file,
+// e.g. "policy:gold" or "policy:1".
+const PolicyRulePrefix = "policy:"
+
+const (
+	PolicyTypeReplication   = "replication"
+	PolicyTypeErasureCoding = "erasure_coding"
+)
+
+// StoragePolicy is a [storage-policy:N] section of swift.conf
+type StoragePolicy struct {
+	Index      uint64
+	Name       string
+	Aliases    []string
+	Default    bool
+	Deprecated bool
+	Type       string
+
+	ECType               string
+	ECNumDataFragments   uint64
+	ECNumParityFragments uint64
+	ECDuplicationFactor  uint64
+	ECObjectSegmentSize  uint64
+}
+
+// BuilderFilename returns the name of the builder file that belongs to the policy
+func (policy StoragePolicy) BuilderFilename() string {
+	if policy.Index == 0 {
+		return "object.builder"
+	}
+	return fmt.Sprintf("object-%d.builder", policy.Index)
+}
+
+// ExpectedReplicas returns the replica count which the ring of the policy must have.
+// Only erasure coding policies define the replica count, for replication policies ok is false.
+func (policy StoragePolicy) ExpectedReplicas() (replicas float64, ok bool) {
+	if policy.Type != PolicyTypeErasureCoding {
+		return 0, false
+	}
+	return float64((policy.ECNumDataFragments + policy.ECNumParityFragments) * policy.ECDuplicationFactor), true
+}
+
+// CheckReplicas verifies that the replica count of a ring matches the policy
+func (policy StoragePolicy) CheckReplicas(ring builderfile.RingInfo) error {
+	replicas, ok := policy.ExpectedReplicas()
+	if !ok {
+		return nil
+	}
+	if math.Abs(ring.Replicas-replicas) > 0.000001 {
+		return fmt.Errorf("policy %d (%s) requires %g replicas (ec_num_data_fragments %d + ec_num_parity_fragments %d, ec_duplication_factor %d) but %s has %g replicas",
+			policy.Index, policy.Name, replicas, policy.ECNumDataFragments, policy.ECNumParityFragments, policy.ECDuplicationFactor, policy.BuilderFilename(), ring.Replicas)
+	}
+	return nil
+}
+
+// FindPolicy returns the policy with the given name, alias or index
+func FindPolicy(policies []StoragePolicy, nameOrIndex string) (StoragePolicy, bool) {
+	for _, policy := range policies {
+		if strings.EqualFold(policy.Name, nameOrIndex) || strconv.FormatUint(policy.Index, 10) == nameOrIndex {
+			return policy, true
+		}
+		for _, alias := range policy.Aliases {
+			if strings.EqualFold(alias, nameOrIndex) {
+				return policy, true
+			}
+		}
+	}
+	return StoragePolicy{}, false
+}
+
+// File reads the storage policies from a swift.conf file
+func File(filename string) []StoragePolicy {
+	file, err := os.Open(filename)
+	if err != nil {
+		logg.Fatal("Reading file failed: %s", err.Error())
+	}
+	defer file.Close()
+
+	policies, err := Parse(file)
+	if err != nil {
+		logg.Fatal("Parsing %s failed: %s", filename, err.Error())
+	}
+	return policies
+}
+
+// Parse extracts the storage policies from the content of a swift.conf file.
+// Like swift itself it falls back to a single replication policy with index 0 if no policy is defined.
+func Parse(input io.Reader) ([]StoragePolicy, error) {
+	var policies []StoragePolicy
+	// index into policies of the section that is currently parsed, -1 outside of storage policy sections
+	current := -1
+
+	scanner := bufio.NewScanner(input)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			indexStr, ok := strings.CutPrefix(section, "storage-policy:")
+			if !ok {
+				current = -1
+				continue
+			}
+			index, err := strconv.ParseUint(indexStr, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid storage policy index %q", lineNumber, indexStr)
+			}
+			policies = append(policies, StoragePolicy{
+				Index:               index,
+				Type:                PolicyTypeReplication,
+				ECDuplicationFactor: 1,
+			})
+			current = len(policies) - 1
+			continue
+		}
+
+		// options outside of storage policy sections are not of interest
+		if current == -1 {
+			continue
+		}
+		policy := &policies[current]
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			key, value, ok = strings.Cut(line, ":")
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value but got %q", lineNumber, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "name":
+			policy.Name = value
+		case "aliases":
+			for alias := range strings.SplitSeq(value, ",") {
+				if alias = strings.TrimSpace(alias); alias != "" {
+					policy.Aliases = append(policy.Aliases, alias)
+				}
+			}
+		case "default":
+			policy.Default, err = parseBool(value)
+		case "deprecated":
+			policy.Deprecated, err = parseBool(value)
+		case "policy_type":
+			policy.Type = value
+		case "ec_type":
+			policy.ECType = value
+		case "ec_num_data_fragments":
+			policy.ECNumDataFragments, err = strconv.ParseUint(value, 10, 64)
+		case "ec_num_parity_fragments":
+			policy.ECNumParityFragments, err = strconv.ParseUint(value, 10, 64)
+		case "ec_duplication_factor":
+			policy.ECDuplicationFactor, err = strconv.ParseUint(value, 10, 64)
+		case "ec_object_segment_size":
+			policy.ECObjectSegmentSize, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value for %s: %w", lineNumber, key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(policies) == 0 {
+		return []StoragePolicy{{Index: 0, Name: "Policy-0", Default: true, Type: PolicyTypeReplication, ECDuplicationFactor: 1}}, nil
+	}
+
+	slices.SortFunc(policies, func(a, b StoragePolicy) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	return policies, validate(policies)
+}
+
+func validate(policies []StoragePolicy) error {
+	var errs []error
+	for i, policy := range policies {
+		if i > 0 && policies[i-1].Index == policy.Index {
+			errs = append(errs, fmt.Errorf("storage policy index %d is defined multiple times", policy.Index))
+		}
+		if policy.Name == "" {
+			errs = append(errs, fmt.Errorf("storage policy %d has no name", policy.Index))
+		}
+		switch policy.Type {
+		case PolicyTypeReplication:
+		case PolicyTypeErasureCoding:
+			if policy.ECNumDataFragments == 0 || policy.ECNumParityFragments == 0 {
+				errs = append(errs, fmt.Errorf("storage policy %d (%s) is missing ec_num_data_fragments or ec_num_parity_fragments", policy.Index, policy.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("storage policy %d (%s) has unknown policy_type %q", policy.Index, policy.Name, policy.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1", "t", "y":
+		return true, nil
+	case "false", "no", "off", "0", "f", "n":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", value)
+}
+
+// ResolvePolicyRules replaces keys in a rule file which refer to a storage policy with the builder filename of that policy.
+// Rings of erasure coding policies get their replica count from the policy unless the rules set one.
+func ResolvePolicyRules(file map[string]rules.RingRules, policies []StoragePolicy) (map[string]rules.RingRules, error) {
+	resolved := make(map[string]rules.RingRules, len(file))
+	for _, ringName := range rules.GetRingNames(file) {
+		ringRules := file[ringName]
+		builderFilename := ringName
+		if nameOrIndex, ok := strings.CutPrefix(ringName, PolicyRulePrefix); ok {
+			if len(policies) == 0 {
+				return nil, fmt.Errorf("%s refers to a storage policy but no storage policies are known", ringName)
+			}
+			policy, ok := FindPolicy(policies, nameOrIndex)
+			if !ok {
+				return nil, fmt.Errorf("%s refers to an unknown storage policy", ringName)
+			}
+			builderFilename = policy.BuilderFilename()
+		}
+
+		if _, exists := resolved[builderFilename]; exists {
+			return nil, fmt.Errorf("rules for %s are defined multiple times", builderFilename)
+		}
+
+		// erasure coding policies dictate the replica count of their ring
+		if policy, ok := PolicyForBuilder(policies, builderFilename); ok {
+			if replicas, ok := policy.ExpectedReplicas(); ok {
+				switch {
+				case ringRules.Replicas == 0:
+					ringRules.Replicas = replicas
+				case math.Abs(ringRules.Replicas-replicas) > 0.000001:
+					return nil, fmt.Errorf("%s sets %g replicas but policy %d (%s) requires %g replicas", ringName, ringRules.Replicas, policy.Index, policy.Name, replicas)
+				}
+			}
+		}
+		resolved[builderFilename] = ringRules
+	}
+
+	return resolved, nil
+}
+
+// PolicyForBuilder returns the policy that a builder file belongs to
+func PolicyForBuilder(policies []StoragePolicy, builderFilename string) (StoragePolicy, bool) {
+	for _, policy := range policies {
+		if policy.BuilderFilename() == filepath.Base(builderFilename) {
+			return policy, true
+		}
+	}
+	return StoragePolicy{}, false
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 SAP SE or an SAP affiliate company
+// SPDX-License-Identifier: Apache-2.0
+
+package swiftconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sapcc/go-bits/assert"
+
+	"github.com/sapcc/swift-ring-artisan/pkg/builderfile"
+	"github.com/sapcc/swift-ring-artisan/pkg/misc"
+	"github.com/sapcc/swift-ring-artisan/pkg/rules"
+)
+
+func TestParseSwiftConf(t *testing.T) {
+	policies := File("../../testing/swift.conf")
+
+	assert.DeepEqual(t, "policies", policies, []StoragePolicy{
+		{Index: 0, Name: "standard", Aliases: []string{"default", "replicated"}, Default: true, Type: PolicyTypeReplication, ECDuplicationFactor: 1},
+		{Index: 1, Name: "gold", Type: PolicyTypeReplication, ECDuplicationFactor: 1},
+		{
+			Index: 2, Name: "deepfreeze10-4", Type: PolicyTypeErasureCoding, ECType: "liberasurecode_rs_vand",
+			ECNumDataFragments: 10, ECNumParityFragments: 4, ECDuplicationFactor: 1, ECObjectSegmentSize: 1048576,
+		},
+	})
+
+	var builderFilenames []string
+	for _, policy := range policies {
+		builderFilenames = append(builderFilenames, policy.BuilderFilename())
+	}
+	assert.DeepEqual(t, "builder filenames", builderFilenames, []string{"object.builder", "object-1.builder", "object-2.builder"})
+}
+
+func TestParseSwiftConfWithoutPolicies(t *testing.T) {
+	policies, err := Parse(strings.NewReader("[swift-hash]\nswift_hash_path_prefix = changeme\n"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "policies", policies, []StoragePolicy{
+		{Index: 0, Name: "Policy-0", Default: true, Type: PolicyTypeReplication, ECDuplicationFactor: 1},
+	})
+}
+
+func TestParseSwiftConfInvalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("[storage-policy:1]\nname = ec\npolicy_type = erasure_coding\nec_num_data_fragments = 10\n"))
+	assert.ErrEqual(t, err, "storage policy 1 (ec) is missing ec_num_data_fragments or ec_num_parity_fragments")
+}
+
+func TestCheckReplicas(t *testing.T) {
+	policies := File("../../testing/swift.conf")
+
+	var ring builderfile.RingInfo
+	misc.ReadYAML("../../testing/builder-output-1.yaml", &ring)
+
+	policy, ok := FindPolicy(policies, "gold")
+	if !ok {
+		t.Fatal("policy gold not found")
+	}
+	assert.ErrEqual(t, policy.CheckReplicas(ring), nil)
+
+	policy, ok = PolicyForBuilder(policies, "/etc/swift/object-2.builder")
+	if !ok {
+		t.Fatal("policy for object-2.builder not found")
+	}
+	assert.ErrEqual(t, policy.CheckReplicas(ring), "policy 2 (deepfreeze10-4) requires 14 replicas (ec_num_data_fragments 10 + ec_num_parity_fragments 4, ec_duplication_factor 1) but object-2.builder has 3 replicas")
+}
+
+func TestResolvePolicyRules(t *testing.T) {
+	policies := File("../../testing/swift.conf")
+
+	var file map[string]rules.RingRules
+	misc.ReadYAML("../../testing/artisan-policies.yaml", &file)
+
+	resolved, err := ResolvePolicyRules(file, policies)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.DeepEqual(t, "ring names", rules.GetRingNames(resolved), []string{"container.builder", "object-2.builder", "object.builder"})
+	assert.DeepEqual(t, "replicas from erasure coding policy", resolved["object-2.builder"].Replicas, 14.0)
+	assert.DeepEqual(t, "replicas of replication policy", resolved["object.builder"].Replicas, 0.0)
+
+	ringRules := file["policy:2"]
+	ringRules.Replicas = 3
+	file["policy:2"] = ringRules
+	_, err = ResolvePolicyRules(file, policies)
+	assert.ErrEqual(t, err, "policy:2 sets 3 replicas but policy 2 (deepfreeze10-4) requires 14 replicas")
+
+	_, err = ResolvePolicyRules(file, nil)
+	assert.ErrEqual(t, err, "policy:2 refers to a storage policy but no storage policies are known")
+}